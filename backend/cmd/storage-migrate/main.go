@@ -0,0 +1,71 @@
+// storage-migrate переносит все объекты медиахранилища из одного бэкенда в другой.
+//
+//	go run ./cmd/storage-migrate -from local -to s3 [-prefix media/] [-delete-source] [-dry-run]
+//
+// Настройки обоих бэкендов берутся из тех же переменных окружения, что и у сервера.
+// Объекты, уже существующие в целевом хранилище с тем же размером, пропускаются,
+// поэтому команду можно безопасно перезапускать после сбоя.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/TryHanger/digital_signage/backend/internal/config"
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
+)
+
+func main() {
+	from := flag.String("from", storage.DriverLocal, "source storage driver (local|s3)")
+	to := flag.String("to", storage.DriverS3, "destination storage driver (local|s3)")
+	prefix := flag.String("prefix", "", "migrate only keys with this prefix")
+	deleteSource := flag.Bool("delete-source", false, "delete objects from the source after copying")
+	dryRun := flag.Bool("dry-run", false, "only list objects that would be copied")
+	flag.Parse()
+
+	if *from == *to {
+		log.Fatal("source and destination drivers are the same")
+	}
+
+	cfg := config.Load()
+	src, err := storage.New(cfg, *from)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dst, err := storage.New(cfg, *to)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var copied, skipped, failed int
+	err = src.List(*prefix, func(info storage.ObjectInfo) error {
+		if existing, err := dst.Stat(info.Key); err == nil && existing.Size == info.Size {
+			skipped++
+			return nil
+		}
+		if *dryRun {
+			log.Printf("would copy %s (%d bytes)", info.Key, info.Size)
+			copied++
+			return nil
+		}
+		if err := storage.Copy(src, dst, info); err != nil {
+			log.Printf("❌ %s: %v", info.Key, err)
+			failed++
+			return nil
+		}
+		if *deleteSource {
+			if err := src.Delete(info.Key); err != nil {
+				log.Printf("⚠️ %s copied, but not deleted from source: %v", info.Key, err)
+			}
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("copied: %d, skipped: %d, failed: %d", copied, skipped, failed)
+	if failed > 0 {
+		log.Fatal("migration finished with errors")
+	}
+}
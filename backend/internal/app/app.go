@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/TryHanger/digital_signage/backend/internal/config"
 	handler2 "github.com/TryHanger/digital_signage/backend/internal/handler"
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	repository2 "github.com/TryHanger/digital_signage/backend/internal/repository"
 	service2 "github.com/TryHanger/digital_signage/backend/internal/service"
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
 	"github.com/gin-contrib/cors"
 
 	"github.com/gin-gonic/gin"
@@ -19,14 +21,26 @@ func Run() {
 	cfg := config.Load()
 	db := repository2.InitDB(cfg)
 
-	db.Migrator().DropTable(&model.Location{}, &model.Monitor{}, &model.MonitorGroup{}, &model.Content{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.ScheduleBlockItem{}, &model.ScheduleException{}, &model.Template{}, &model.TemplateBlock{}, &model.TemplateContent{})
-	db.AutoMigrate(&model.Location{}, &model.Monitor{}, &model.MonitorGroup{}, &model.Content{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.ScheduleBlockItem{}, &model.ScheduleException{}, &model.Template{}, &model.TemplateBlock{}, &model.TemplateContent{})
+	db.Migrator().DropTable(&model.Location{}, &model.Monitor{}, &model.MonitorGroup{}, &model.Content{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.ScheduleBlockItem{}, &model.ScheduleException{}, &model.Template{}, &model.TemplateBlock{}, &model.TemplateContent{}, &model.DefaultPlaylist{}, &model.DefaultPlaylistItem{}, &model.Upload{}, &model.FeedItem{}, &model.Folder{}, &model.ContentVersion{}, &model.Playlist{}, &model.PlaylistItem{}, &model.TemplateZone{}, &model.TemplateVersion{})
+	db.AutoMigrate(&model.Location{}, &model.Monitor{}, &model.MonitorGroup{}, &model.Content{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.Schedule{}, &model.ScheduleBlock{}, &model.ScheduleBlockItem{}, &model.ScheduleException{}, &model.Template{}, &model.TemplateBlock{}, &model.TemplateContent{}, &model.DefaultPlaylist{}, &model.DefaultPlaylistItem{}, &model.Upload{}, &model.FeedItem{}, &model.Folder{}, &model.ContentVersion{}, &model.Playlist{}, &model.PlaylistItem{}, &model.TemplateZone{}, &model.TemplateVersion{})
+	if err := repository2.MigrateSearch(db); err != nil {
+		log.Fatal("Не удалось создать индекс поиска контента:", err)
+	}
+	if err := repository2.MigrateReviewStatus(db); err != nil {
+		log.Fatal("Не удалось проставить статус согласования контенту:", err)
+	}
 	// --- Repositories ---
 	monitorRepo := repository2.NewMonitorRepository(db)
 	contentRepo := repository2.NewContentRepository(db)
 	scheduleRepo := repository2.NewScheduleRepository(db)
 	locationRepo := repository2.NewLocationRepository(db)
 	templateRepo := repository2.NewTemplateRepository(db)
+	defaultPlaylistRepo := repository2.NewDefaultPlaylistRepository(db)
+	uploadRepo := repository2.NewUploadRepository(db)
+	feedRepo := repository2.NewFeedRepository(db)
+	folderRepo := repository2.NewFolderRepository(db)
+	storageRepo := repository2.NewStorageRepository(db)
+	playlistRepo := repository2.NewPlaylistRepository(db)
 
 	// --- Cache ---
 	//scheduleCache := cache.NewScheduleCache()
@@ -34,12 +48,36 @@ func Run() {
 	// --- Notifier ---
 	//notifier := socket.NewWebSocketNotifier(monitorRepo, scheduleCache)
 
+	// --- Storage ---
+	mediaStore, err := storage.New(cfg, cfg.StorageDriver)
+	if err != nil {
+		log.Fatal("Не удалось подготовить хранилище:", err)
+	}
+
 	// --- Services ---
 	monitorService := service2.NewMonitorService(monitorRepo)
-	contentService := service2.NewContentService(contentRepo)
-	scheduleService := service2.NewScheduleService(scheduleRepo)
+	storageService := service2.NewStorageService(storageRepo, locationRepo, cfg.DefaultLocationQuota)
+	contentService := service2.NewContentService(contentRepo, mediaStore, storageService, cfg.MaxUploadSize, cfg.ContentURLAllowlist)
+	scheduleService := service2.NewScheduleService(scheduleRepo, contentRepo, playlistRepo)
 	locationService := service2.NewLocationService(locationRepo)
-	templateService := service2.NewTemplateService(templateRepo)
+	templateService := service2.NewTemplateService(templateRepo, contentRepo, playlistRepo)
+	defaultPlaylistService := service2.NewDefaultPlaylistService(defaultPlaylistRepo)
+	uploadService, err := service2.NewUploadService(uploadRepo, contentService, cfg.UploadDir, cfg.UploadTTL)
+	if err != nil {
+		log.Fatal("Не удалось подготовить каталог загрузок:", err)
+	}
+	renditionSigner := service2.NewRenditionSigner(cfg.PresignSecret)
+	playerService := service2.NewPlayerService(monitorRepo, scheduleRepo, defaultPlaylistRepo, playlistRepo, renditionSigner)
+	feedService := service2.NewFeedService(contentRepo, feedRepo, nil)
+	folderService := service2.NewFolderService(folderRepo)
+	expiryService := service2.NewExpiryService(contentRepo, scheduleRepo)
+	importService := service2.NewImportService(contentService, cfg.MaxImportSize)
+	playlistService := service2.NewPlaylistService(playlistRepo, contentRepo, scheduleService, templateService)
+	templateBundleService := service2.NewTemplateBundleService(templateService, contentService, cfg.MaxImportSize)
+	renditionService, err := service2.NewRenditionService(contentRepo, mediaStore, renditionSigner, cfg.RenditionDir, cfg.RenditionTTL)
+	if err != nil {
+		log.Fatal("Не удалось подготовить каталог рендишенов:", err)
+	}
 
 	// --- Handlers ---
 	monitorHandler := handler2.NewMonitorHandler(monitorService)
@@ -48,6 +86,18 @@ func Run() {
 	locationHandler := handler2.NewLocationHandler(locationService)
 	//cacheHandler := handler2.NewCacheHandler(scheduleCache)
 	templateHandler := handler2.NewTemplateHandler(templateService)
+	playerHandler := handler2.NewPlayerHandler(playerService)
+	mediaHandler := handler2.NewMediaHandler(mediaStore, cfg.PresignSecret)
+	defaultPlaylistHandler := handler2.NewDefaultPlaylistHandler(defaultPlaylistService)
+	uploadHandler := handler2.NewUploadHandler(uploadService)
+	feedHandler := handler2.NewFeedHandler(feedService)
+	folderHandler := handler2.NewFolderHandler(folderService)
+	expiryHandler := handler2.NewExpiryHandler(expiryService)
+	importHandler := handler2.NewImportHandler(importService)
+	storageHandler := handler2.NewStorageHandler(storageService)
+	renditionHandler := handler2.NewRenditionHandler(renditionService)
+	playlistHandler := handler2.NewPlaylistHandler(playlistService)
+	templateBundleHandler := handler2.NewTemplateBundleHandler(templateBundleService)
 
 	// --- Gin ---
 	r := gin.Default()
@@ -58,9 +108,11 @@ func Run() {
 			"http://127.0.0.1:3000",
 			"http://localhost:5173",
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "HEAD", "DELETE", "OPTIONS"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-User",
+			"Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset", "Upload-Checksum"},
+		ExposeHeaders: []string{"Content-Length", "Location", "Content-Location",
+			"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires"},
 		AllowCredentials: true, // разрешаем куки и авторизацию
 	}))
 
@@ -90,6 +142,18 @@ func Run() {
 	scheduleHandler.RegisterRoutes(api)
 	locationHandler.RegisterRoutes(api)
 	templateHandler.RegisterRoutes(api)
+	playerHandler.RegisterRoutes(api)
+	mediaHandler.RegisterRoutes(api)
+	defaultPlaylistHandler.RegisterRoutes(api)
+	uploadHandler.RegisterRoutes(api)
+	feedHandler.RegisterRoutes(api)
+	folderHandler.RegisterRoutes(api)
+	expiryHandler.RegisterRoutes(api)
+	importHandler.RegisterRoutes(api)
+	storageHandler.RegisterRoutes(api)
+	renditionHandler.RegisterRoutes(api)
+	playlistHandler.RegisterRoutes(api)
+	templateBundleHandler.RegisterRoutes(api)
 
 	//scheduleService.StartScheduler()
 	//
@@ -98,12 +162,27 @@ func Run() {
 	//	scheduleService.SendSchedulesToMonitor(monitorID)
 	//})
 
+	// 🧹 Очистка брошенных возобновляемых загрузок
+	uploadService.StartCleanup(30 * time.Minute)
+
+	// 🖼️ Очистка неиспользуемых рендишенов
+	renditionService.StartCleanup(6 * time.Hour)
+
+	// 🩺 Проверка доступности веб-страниц и трансляций
+	service2.NewHealthChecker(contentRepo, nil).Start(cfg.HealthCheckInterval)
+
+	// 📰 Обновление RSS/Atom-лент
+	feedService.Start(time.Minute)
+
+	// ⏳ Ежедневный отчёт об истекающем контенте
+	expiryService.Start()
+
 	// ⏰ Запуск планировщика
 	// scheduleService.StartScheduler()
 
 	// 🚀 Старт сервера
 	fmt.Println("🚀 Сервер запущен на порту", cfg.ServerPort)
-	err := http.ListenAndServe(":"+cfg.ServerPort, r)
+	err = http.ListenAndServe(":"+cfg.ServerPort, r)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -4,6 +4,9 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -13,6 +16,39 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	ServerPort string
+
+	// Хранилище медиафайлов
+	StorageDriver string // local | s3
+	StorageDir    string
+	MaxUploadSize int64 // байты
+	PublicURL     string
+	PresignSecret string
+
+	// Возобновляемые загрузки (tus): каталог для частей и срок жизни брошенных загрузок
+	UploadDir string
+	UploadTTL time.Duration
+
+	// Кэш изображений, подогнанных под разрешение мониторов, и срок хранения неиспользуемых
+	RenditionDir string
+	RenditionTTL time.Duration
+
+	// Импорт контента из ZIP-архива: предел размера архива, байты
+	MaxImportSize int64
+
+	// Квота локации на файлы контента по умолчанию, байты (0 — без ограничения)
+	DefaultLocationQuota int64
+
+	// Веб-контент и трансляции: разрешённые домены (пусто — любые) и период проверки доступности
+	ContentURLAllowlist []string
+	HealthCheckInterval time.Duration
+
+	// S3-совместимое хранилище (STORAGE_DRIVER=s3)
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
 }
 
 func Load() *Config {
@@ -42,6 +78,29 @@ func Load() *Config {
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBName:     os.Getenv("DB_NAME"),
 		ServerPort: os.Getenv("SERVER_PORT"),
+
+		StorageDriver: getEnv("STORAGE_DRIVER", "local"),
+		StorageDir:    getEnv("STORAGE_DIR", "storage"),
+		MaxUploadSize: getEnvInt("MAX_UPLOAD_SIZE_MB", 500) << 20,
+		PublicURL:     getEnv("PUBLIC_URL", "http://localhost:8080"),
+		PresignSecret: os.Getenv("PRESIGN_SECRET"),
+		UploadDir:     getEnv("UPLOAD_DIR", "uploads"),
+		UploadTTL:     time.Duration(getEnvInt("UPLOAD_TTL_HOURS", 24)) * time.Hour,
+		MaxImportSize: getEnvInt("MAX_IMPORT_SIZE_MB", 2048) << 20,
+		RenditionDir:  getEnv("RENDITION_DIR", "renditions"),
+		RenditionTTL:  time.Duration(getEnvInt("RENDITION_TTL_DAYS", 30)) * 24 * time.Hour,
+
+		DefaultLocationQuota: getEnvInt("LOCATION_QUOTA_MB", 0) << 20,
+
+		ContentURLAllowlist: getEnvList("CONTENT_URL_ALLOWLIST"),
+		HealthCheckInterval: time.Duration(getEnvInt("HEALTH_CHECK_INTERVAL_MIN", 5)) * time.Minute,
+
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Region:    getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle: getEnv("S3_PATH_STYLE", "true") == "true",
 	}
 
 	if cfg.DBHost == "" {
@@ -50,3 +109,33 @@ func Load() *Config {
 
 	return cfg
 }
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// getEnvList читает список через запятую, пропуская пустые элементы
+func getEnvList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, strings.ToLower(v))
+		}
+	}
+	return list
+}
+
+func getEnvInt(key string, fallback int64) int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		log.Fatalf("Некорректное значение %s: %v", key, err)
+	}
+	return n
+}
@@ -0,0 +1,342 @@
+// Package feed разбирает ленты RSS 2.0, RSS 1.0 (RDF) и Atom и приводит записи
+// к общему виду: заголовок, краткий текст, картинка, ссылка и дата публикации.
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSummaryLength — предел длины краткого текста записи в символах
+const maxSummaryLength = 500
+
+var ErrUnknownFormat = errors.New("feed: unknown format")
+
+// Feed — разобранная лента
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+// Item — запись ленты в нормализованном виде
+type Item struct {
+	GUID      string
+	Title     string
+	Summary   string // без HTML
+	Link      string
+	Image     string
+	Published time.Time // нулевое, если дата не указана или не распознана
+}
+
+// Parse определяет формат по корневому элементу и разбирает ленту.
+// Записи возвращаются от новых к старым.
+func Parse(r io.Reader) (*Feed, error) {
+	var doc document
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var f *Feed
+	switch strings.ToLower(doc.XMLName.Local) {
+	case "rss":
+		f = doc.Channel.feed(doc.Channel.Items)
+	case "rdf":
+		f = doc.Channel.feed(doc.Items)
+	case "feed":
+		f = doc.atomFeed()
+	default:
+		return nil, ErrUnknownFormat
+	}
+	sort.SliceStable(f.Items, func(i, j int) bool { return f.Items[i].Published.After(f.Items[j].Published) })
+	return f, nil
+}
+
+// Filter — отбор записей по словам: запись должна содержать хотя бы одно из include
+// (если они заданы) и ни одного из exclude — в заголовке или тексте, без учёта регистра
+type Filter struct {
+	include []string
+	exclude []string
+}
+
+// ParseFilter разбирает слова через запятую; слово с "-" в начале исключает записи
+func ParseFilter(expr string) Filter {
+	var f Filter
+	for _, term := range strings.Split(strings.ToLower(expr), ",") {
+		term = strings.TrimSpace(term)
+		switch {
+		case term == "" || term == "-":
+		case strings.HasPrefix(term, "-"):
+			f.exclude = append(f.exclude, strings.TrimSpace(term[1:]))
+		default:
+			f.include = append(f.include, term)
+		}
+	}
+	return f
+}
+
+func (f Filter) Match(title, summary string) bool {
+	text := strings.ToLower(title + "\n" + summary)
+	if len(f.include) > 0 && !containsAny(text, f.include) {
+		return false
+	}
+	return !containsAny(text, f.exclude)
+}
+
+func containsAny(text string, terms []string) bool {
+	for _, t := range terms {
+		if strings.Contains(text, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// document покрывает все три формата: у RSS записи внутри channel,
+// у RDF — рядом с channel, у Atom — entry в корне
+type document struct {
+	XMLName xml.Name
+	Channel channel     `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Title   text        `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type channel struct {
+	Title string    `xml:"title"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	GUID        string      `xml:"guid"`
+	Title       string      `xml:"title"`
+	Link        string      `xml:"link"`
+	Description string      `xml:"description"`
+	Encoded     string      `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string      `xml:"pubDate"`
+	Date        string      `xml:"http://purl.org/dc/elements/1.1/ date"`
+	About       string      `xml:"about,attr"`
+	Enclosures  []enclosure `xml:"enclosure"`
+	Media       []media     `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails  []media     `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Groups      []struct {
+		Media      []media `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnails []media `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+	ItunesImage struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+}
+
+type enclosure struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type media struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     text       `xml:"title"`
+	Summary   text       `xml:"summary"`
+	Content   text       `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link"`
+	Media     []media    `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnail []media    `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// text — текстовый элемент Atom: содержимое может быть и текстом, и XHTML-разметкой
+type text struct {
+	Type  string `xml:"type,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t text) String() string {
+	s := strings.TrimSpace(t.Inner)
+	if strings.HasPrefix(s, "<![CDATA[") && strings.HasSuffix(s, "]]>") {
+		return s[len("<![CDATA[") : len(s)-len("]]>")]
+	}
+	if t.Type == "xhtml" {
+		return s
+	}
+	return html.UnescapeString(s)
+}
+
+func (c channel) feed(items []rssItem) *Feed {
+	f := &Feed{Title: strings.TrimSpace(c.Title)}
+	for _, it := range items {
+		body := it.Description
+		if body == "" {
+			body = it.Encoded
+		}
+		item := Item{
+			GUID:    firstNonEmpty(it.GUID, it.About, it.Link, it.Title),
+			Title:   cleanText(it.Title),
+			Summary: summarize(body),
+			Link:    strings.TrimSpace(it.Link),
+			Image:   rssImage(it, body),
+		}
+		item.Published = parseDate(firstNonEmpty(it.PubDate, it.Date))
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+func (d document) atomFeed() *Feed {
+	f := &Feed{Title: cleanText(d.Title.String())}
+	for _, e := range d.Entries {
+		body := e.Summary.String()
+		if body == "" {
+			body = e.Content.String()
+		}
+		item := Item{
+			Title:   cleanText(e.Title.String()),
+			Summary: summarize(body),
+		}
+		for _, l := range e.Links {
+			switch {
+			case (l.Rel == "" || l.Rel == "alternate") && item.Link == "":
+				item.Link = l.Href
+			case l.Rel == "enclosure" && strings.HasPrefix(l.Type, "image/") && item.Image == "":
+				item.Image = l.Href
+			}
+		}
+		if item.Image == "" {
+			item.Image = firstNonEmpty(mediaImage(e.Media), mediaImage(e.Thumbnail), htmlImage(e.Content.String()), htmlImage(body))
+		}
+		item.GUID = firstNonEmpty(e.ID, item.Link, item.Title)
+		item.Published = parseDate(firstNonEmpty(e.Published, e.Updated))
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+func rssImage(it rssItem, body string) string {
+	for _, e := range it.Enclosures {
+		if strings.HasPrefix(e.Type, "image/") {
+			return e.URL
+		}
+	}
+	candidates := []string{mediaImage(it.Media), mediaImage(it.Thumbnails)}
+	for _, g := range it.Groups {
+		candidates = append(candidates, mediaImage(g.Media), mediaImage(g.Thumbnails))
+	}
+	candidates = append(candidates, it.ItunesImage.Href, htmlImage(body), htmlImage(it.Encoded))
+	return firstNonEmpty(candidates...)
+}
+
+// mediaImage — первая картинка среди media:content / media:thumbnail
+func mediaImage(items []media) string {
+	for _, m := range items {
+		if m.URL != "" && (m.Medium == "image" || strings.HasPrefix(m.Type, "image/") || (m.Medium == "" && m.Type == "")) {
+			return m.URL
+		}
+	}
+	return ""
+}
+
+var imgSrc = regexp.MustCompile(`(?i)<img[^>]+src\s*=\s*["']([^"']+)["']`)
+
+// htmlImage — адрес первой картинки в HTML-тексте записи
+func htmlImage(s string) string {
+	if m := imgSrc.FindStringSubmatch(s); m != nil {
+		return html.UnescapeString(m[1])
+	}
+	return ""
+}
+
+var (
+	tags   = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaces = regexp.MustCompile(`\s+`)
+)
+
+// cleanText убирает разметку и лишние пробелы
+func cleanText(s string) string {
+	s = tags.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+}
+
+// summarize — текст без HTML, обрезанный по границе слова
+func summarize(s string) string {
+	s = cleanText(s)
+	if utf8.RuneCountInString(s) <= maxSummaryLength {
+		return s
+	}
+	runes := []rune(s)[:maxSummaryLength]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > maxSummaryLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// charsetReader поддерживает однобайтовые кодировки, встречающиеся в старых лентах
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return &singleByteReader{r: input, table: nil}, nil
+	case "windows-1251", "cp1251":
+		return &singleByteReader{r: input, table: &cp1251}, nil
+	default:
+		return nil, errors.New("feed: unsupported charset " + charset)
+	}
+}
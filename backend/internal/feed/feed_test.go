@@ -0,0 +1,157 @@
+package feed
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, name string) (*Feed, error) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+func mustParseFixture(t *testing.T, name string) *Feed {
+	t.Helper()
+	f, err := parseFixture(t, name)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return f
+}
+
+func TestParseRSS2(t *testing.T) {
+	f := mustParseFixture(t, "rss2.xml")
+	if f.Title != "Городские новости" {
+		t.Fatalf("title = %q", f.Title)
+	}
+	want := []Item{
+		{
+			GUID:      "https://news.example.com/2",
+			Title:     "Новая запись",
+			Summary:   "Текст с ссылкой и картинкой",
+			Link:      "https://news.example.com/2",
+			Image:     "https://news.example.com/2.png",
+			Published: time.Date(2026, 10, 14, 18, 30, 0, 0, time.UTC),
+		},
+		{
+			GUID:      "https://news.example.com/3",
+			Title:     "Запись с media",
+			Summary:   "Полный текст",
+			Link:      "https://news.example.com/3",
+			Image:     "https://news.example.com/3.jpg",
+			Published: time.Date(2026, 10, 13, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			GUID:      "news-1",
+			Title:     "Старая запись",
+			Summary:   "Первая & короткая",
+			Link:      "https://news.example.com/1",
+			Image:     "https://news.example.com/1.jpg",
+			Published: time.Date(2026, 10, 12, 6, 0, 0, 0, time.UTC),
+		},
+	}
+	checkItems(t, f.Items, want)
+}
+
+func TestParseAtom(t *testing.T) {
+	f := mustParseFixture(t, "atom.xml")
+	if f.Title != "Блог компании & партнёров" {
+		t.Fatalf("title = %q", f.Title)
+	}
+	want := []Item{
+		{
+			GUID:      "urn:uuid:2",
+			Title:     "Второй пост",
+			Summary:   "Подробности",
+			Link:      "https://blog.example.com/2",
+			Image:     "https://blog.example.com/2.jpg",
+			Published: time.Date(2026, 10, 15, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			GUID:      "urn:uuid:1",
+			Title:     "Первый пост",
+			Summary:   "Коротко о главном",
+			Link:      "https://blog.example.com/1",
+			Image:     "https://blog.example.com/1.png",
+			Published: time.Date(2026, 10, 10, 8, 0, 0, 0, time.UTC),
+		},
+	}
+	checkItems(t, f.Items, want)
+}
+
+func TestParseRDF(t *testing.T) {
+	f := mustParseFixture(t, "rdf.xml")
+	if f.Title != "Старый сайт" || len(f.Items) != 1 {
+		t.Fatalf("feed = %+v", f)
+	}
+	it := f.Items[0]
+	if it.GUID != "https://old.example.com/a" || it.Title != "Запись RDF" || !it.Published.Equal(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("item = %+v", it)
+	}
+}
+
+func TestParseWindows1251(t *testing.T) {
+	f := mustParseFixture(t, "cp1251.xml")
+	if f.Title != "Новости" || len(f.Items) != 1 || f.Items[0].Title != "Привет" {
+		t.Fatalf("feed = %+v", f)
+	}
+}
+
+func TestParseRejectsBrokenDocuments(t *testing.T) {
+	if _, err := parseFixture(t, "malformed.xml"); err == nil {
+		t.Fatal("truncated feed parsed without error")
+	}
+	if _, err := parseFixture(t, "html.xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("html err = %v, want ErrUnknownFormat", err)
+	}
+	if _, err := Parse(strings.NewReader(`<?xml version="1.0" encoding="koi8-r"?><rss/>`)); err == nil {
+		t.Fatal("unsupported charset parsed without error")
+	}
+}
+
+func TestSummaryIsTruncated(t *testing.T) {
+	long := strings.Repeat("слово ", 200)
+	s := summarize(long)
+	if n := len([]rune(s)); n > maxSummaryLength+1 || !strings.HasSuffix(s, "слово…") {
+		t.Fatalf("summary of %d runes: %q", n, s)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f := ParseFilter("погода, -реклама")
+	tests := []struct {
+		title, summary string
+		want           bool
+	}{
+		{"Погода на завтра", "", true},
+		{"Новости", "о погоде", false},
+		{"Погода", "Реклама зонтов", false},
+	}
+	for _, tt := range tests {
+		if got := f.Match(tt.title, tt.summary); got != tt.want {
+			t.Fatalf("Match(%q, %q) = %v, want %v", tt.title, tt.summary, got, tt.want)
+		}
+	}
+}
+
+func checkItems(t *testing.T, got, want []Item) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.GUID != w.GUID || g.Title != w.Title || g.Summary != w.Summary || g.Link != w.Link || g.Image != w.Image || !g.Published.Equal(w.Published) {
+			t.Errorf("item %d:\n got %+v\nwant %+v", i, g, w)
+		}
+	}
+}
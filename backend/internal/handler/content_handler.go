@@ -1,10 +1,19 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/TryHanger/digital_signage/backend/internal/media"
 	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
 	"github.com/TryHanger/digital_signage/backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -22,10 +31,25 @@ func (h *ContentHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	group := rg.Group("/contents")
 	{
 		group.POST("", h.Create)
+		group.POST("/upload", h.Upload)
 		group.GET("", h.GetAll)
+		group.GET("/tags", h.Tags)
 		group.GET("/:id", h.GetByID)
 		group.PUT("/:id", h.Update)
 		group.DELETE("/:id", h.Delete)
+		group.GET("/:id/usages", h.Usages)
+		group.GET("/:id/download", h.Download)
+		group.HEAD("/:id/download", h.Download)
+		group.GET("/:id/presign", h.Presign)
+		group.GET("/:id/thumbnail", h.Thumbnail)
+		group.PUT("/:id/file", h.ReplaceFile)
+		group.PUT("/:id/poster", h.UploadPoster)
+		group.POST("/:id/submit", h.Submit)
+		group.POST("/:id/approve", h.Approve)
+		group.POST("/:id/reject", h.Reject)
+		group.GET("/:id/versions", h.Versions)
+		group.GET("/:id/versions/diff", h.DiffVersions)
+		group.POST("/:id/versions/:version/rollback", h.Rollback)
 	}
 }
 
@@ -36,19 +60,39 @@ func (h *ContentHandler) Create(c *gin.Context) {
 		return
 	}
 	if err := h.service.Create(&content); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, content)
 }
 
+// GET /contents?q=&type=&tag=&folderId=&locationId=&recursive=&uploadedFrom=&uploadedTo=&orientation=&reviewStatus=&page=&pageSize=
+// type и tag можно повторять; folderId=0 — контент вне папок; даты — YYYY-MM-DD или RFC3339
 func (h *ContentHandler) GetAll(c *gin.Context) {
-	contents, err := h.service.GetAll()
+	filter, err := parseContentFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+
+	result, err := h.service.Search(filter, page, pageSize)
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GET /contents/tags
+func (h *ContentHandler) Tags(c *gin.Context) {
+	tags, err := h.service.Tags()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, contents)
+	c.JSON(http.StatusOK, tags)
 }
 
 func (h *ContentHandler) GetByID(c *gin.Context) {
@@ -70,17 +114,475 @@ func (h *ContentHandler) Update(c *gin.Context) {
 	}
 	content.ID = uint(id)
 	if err := h.service.Update(&content); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, content)
 }
 
+// DELETE /contents/:id?mode=cascade | ?mode=replace&with=<id>
+// Контент, на который ссылаются шаблоны, расписания или плейлисты, без mode не удаляется (409)
 func (h *ContentHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid content id"})
+		return
+	}
+	with := 0
+	if v := c.Query("with"); v != "" {
+		if with, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid replacement content id in with"})
+			return
+		}
+	}
+	err = h.service.Delete(uint(id), c.Query("mode"), uint(with))
+	if errors.Is(err, service.ErrContentInUse) {
+		usages, usagesErr := h.service.Usages(uint(id))
+		if usagesErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": usagesErr.Error()})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "usages": usages})
+		return
+	}
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /contents/:id/usages
+func (h *ContentHandler) Usages(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid content id"})
+		return
+	}
+	usages, err := h.service.Usages(uint(id))
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usages)
+}
+
+// POST /contents/upload (multipart: file, poster, title, description, duration, folderId, locationId, tags, validFrom, validUntil)
+// Файл не буферизуется в памяти: части формы читаются потоком.
+func (h *ContentHandler) Upload(c *gin.Context) {
+	var content model.Content
+	form, ok := h.readUploadForm(c, &content)
+	if !ok {
+		return
+	}
+	if form.file == nil {
+		h.service.ReleaseUpload(nil, form.poster)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	content.Thumbnail = form.poster
+	if err := h.service.CreateUploaded(&content, form.file, currentUser(c)); err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, content)
+}
+
+// PUT /contents/:id/file (multipart: file, poster) — замена файла; превью строятся заново
+func (h *ContentHandler) ReplaceFile(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if _, err := h.service.GetByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	form, ok := h.readUploadForm(c, &model.Content{})
+	if !ok {
+		return
+	}
+	if form.file == nil {
+		h.service.ReleaseUpload(nil, form.poster)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	content, err := h.service.ReplaceFile(uint(id), form.file, form.poster, currentUser(c))
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, content)
+}
+
+// PUT /contents/:id/poster (multipart: poster) — постер для видео
+func (h *ContentHandler) UploadPoster(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := h.service.Delete(uint(id)); err != nil {
+	if _, err := h.service.GetByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	form, ok := h.readUploadForm(c, &model.Content{})
+	if !ok {
+		return
+	}
+	if form.poster == "" {
+		h.service.ReleaseUpload(form.file, "")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "poster is required"})
+		return
+	}
+	content, err := h.service.SetPoster(uint(id), form.poster)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, content)
+}
+
+// GET /contents/:id/thumbnail?size=small|medium|large
+func (h *ContentHandler) Thumbnail(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	size := c.DefaultQuery("size", media.DefaultThumbnailSize)
+	if _, ok := media.ThumbnailSizes[size]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown thumbnail size %q", size)})
+		return
+	}
+	content, file, err := h.service.OpenThumbnail(uint(id), size)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	// превью неизменны для данного источника: ETag меняется вместе с файлом или постером
+	c.Header("ETag", fmt.Sprintf(`"%s-%s"`, content.Thumbnail, size))
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Header("Content-Type", "image/jpeg")
+	http.ServeContent(c.Writer, c.Request, "", content.UpdatedAt, file)
+}
+
+// uploadForm — файл и постер, прочитанные из multipart-формы
+type uploadForm struct {
+	file   *service.StoredFile
+	poster string
+}
+
+// readUploadForm читает multipart-форму потоком: file и poster сохраняются в хранилище,
+// остальные поля заполняют content. При ошибке сам пишет ответ, удаляет уже сохранённые
+// файл и постер и возвращает false.
+func (h *ContentHandler) readUploadForm(c *gin.Context, content *model.Content) (*uploadForm, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxRequestSize())
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	form := &uploadForm{}
+	if !h.readUploadParts(c, reader, content, form) {
+		h.service.ReleaseUpload(form.file, form.poster)
+		return nil, false
+	}
+	return form, true
+}
+
+// readUploadParts читает части формы в form и content; при ошибке пишет ответ и возвращает false
+func (h *ContentHandler) readUploadParts(c *gin.Context, reader *multipart.Reader, content *model.Content, form *uploadForm) bool {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+
+		switch part.FormName() {
+		case "file":
+			form.file, err = h.service.StoreFile(part)
+			if err != nil {
+				c.JSON(uploadStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+				return false
+			}
+			if content.Title == "" {
+				content.Title = part.FileName()
+			}
+			continue
+		case "poster":
+			form.poster, err = h.service.StorePoster(part)
+			if err != nil {
+				c.JSON(uploadStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+				return false
+			}
+			continue
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, 64<<10))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		if err := setUploadField(content, part.FormName(), string(value)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+	return true
+}
+
+// POST /contents/:id/submit — отправить на согласование
+func (h *ContentHandler) Submit(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	h.respondReview(c, func(user service.Reviewer, _ string) (*model.Content, error) { return h.service.Submit(uint(id), user) })
+}
+
+// POST /contents/:id/approve {"comment": "..."} — роль head-office, не автор контента
+func (h *ContentHandler) Approve(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	h.respondReview(c, func(reviewer service.Reviewer, comment string) (*model.Content, error) {
+		return h.service.Approve(uint(id), reviewer, comment)
+	})
+}
+
+// POST /contents/:id/reject {"comment": "..."} — роль head-office, причина обязательна
+func (h *ContentHandler) Reject(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	h.respondReview(c, func(reviewer service.Reviewer, comment string) (*model.Content, error) {
+		return h.service.Reject(uint(id), reviewer, comment)
+	})
+}
+
+// respondReview читает необязательный комментарий и выполняет переход согласования
+// от имени текущего пользователя (X-User, роли — X-User-Roles)
+func (h *ContentHandler) respondReview(c *gin.Context, transition func(reviewer service.Reviewer, comment string) (*model.Content, error)) {
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	content, err := transition(currentReviewer(c), body.Comment)
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, content)
+}
+
+// GET /contents/:id/versions
+func (h *ContentHandler) Versions(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	versions, err := h.service.Versions(uint(id))
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// GET /contents/:id/versions/diff?from=1&to=2
+func (h *ContentHandler) DiffVersions(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to versions are required"})
+		return
+	}
+	changes, err := h.service.DiffVersions(uint(id), from, to)
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "changes": changes})
+}
+
+// POST /contents/:id/versions/:version/rollback
+func (h *ContentHandler) Rollback(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+	content, err := h.service.Rollback(uint(id), version, currentUser(c))
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, content)
+}
+
+// contentDisposition собирает заголовок по RFC 6266: названия не в ASCII (кириллица)
+// передаются через filename*
+func contentDisposition(disposition, filename string) string {
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); v != "" {
+		return v
+	}
+	return disposition
+}
+
+// contentErrorStatus — 400 для нарушений правил вида контента, 404 для несуществующего,
+// 409 для недопустимого перехода согласования, иначе 500
+func contentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidContent):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrContentNotFound), errors.Is(err, service.ErrVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrReviewState):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrReviewForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	}
+	return http.StatusInternalServerError
+}
+
+// uploadStatus — 413 для слишком большого файла, иначе fallback
+func uploadStatus(err error, fallback int) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, service.ErrFileTooLarge) || errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return fallback
+}
+
+// GET|HEAD /contents/:id/download
+// Поддерживает Range (докачка), а также If-None-Match / If-Modified-Since (ответ 304):
+// ETag строгий и равен SHA-256 файла, поэтому плеер может пропустить уже скачанное.
+func (h *ContentHandler) Download(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	content, file, err := h.service.OpenFile(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	c.Header("ETag", `"`+content.Checksum+`"`)
+	c.Header("Content-Type", content.MimeType)
+	c.Header("Content-Disposition", contentDisposition("inline", content.Title))
+	http.ServeContent(c.Writer, c.Request, "", content.UpdatedAt, file)
+}
+
+// GET /contents/:id/presign — временная прямая ссылка на файл (в S3 или через /media)
+func (h *ContentHandler) Presign(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	const ttl = 15 * time.Minute
+	url, err := h.service.PresignURL(uint(id), ttl)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"url": url, "expiresAt": time.Now().Add(ttl)})
+}
+
+func parseContentFilter(c *gin.Context) (repository.ContentFilter, error) {
+	filter := repository.ContentFilter{
+		Query:        c.Query("q"),
+		Types:        c.QueryArray("type"),
+		Tags:         c.QueryArray("tag"),
+		Recursive:    c.Query("recursive") == "true",
+		Orientation:  c.Query("orientation"),
+		ReviewStatus: c.Query("reviewStatus"),
+	}
+	if v := c.Query("folderId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid folderId %q", v)
+		}
+		folderID := uint(id)
+		filter.FolderID = &folderID
+	}
+	if v := c.Query("locationId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid locationId %q", v)
+		}
+		locationID := uint(id)
+		filter.LocationID = &locationID
+	}
+	var err error
+	if filter.UploadedFrom, err = parseDateParam(c.Query("uploadedFrom"), false); err != nil {
+		return filter, err
+	}
+	if filter.UploadedTo, err = parseDateParam(c.Query("uploadedTo"), true); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseDateParam разбирает дату или время; для даты с endOfDay возвращается начало следующего дня
+func parseDateParam(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", v)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// setUploadField заполняет поле контента из текстовой части multipart-формы
+func setUploadField(content *model.Content, name, value string) error {
+	switch name {
+	case "title":
+		if value != "" {
+			content.Title = value
+		}
+	case "description":
+		content.Description = value
+	case "duration":
+		if value == "" {
+			return nil
+		}
+		d, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		content.Duration = d
+	case "folderId":
+		if value == "" {
+			return nil
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid folderId %q", value)
+		}
+		folderID := uint(id)
+		content.FolderID = &folderID
+	case "locationId":
+		if value == "" {
+			return nil
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid locationId %q", value)
+		}
+		locationID := uint(id)
+		content.LocationID = &locationID
+	case "tags":
+		content.Tags = strings.Split(value, ",")
+	case "validFrom", "validUntil":
+		t, err := parseDateParam(value, false)
+		if err != nil {
+			return err
+		}
+		if name == "validFrom" {
+			content.ValidFrom = t
+		} else {
+			content.ValidUntil = t
+		}
+	}
+	return nil
 }
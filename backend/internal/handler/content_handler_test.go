@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestContentHandlerRejectsInvalidIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewContentHandler(nil).RegisterRoutes(r.Group("/api/v1"))
+
+	tests := []struct {
+		method, target string
+	}{
+		{http.MethodDelete, "/api/v1/contents/abc"},
+		{http.MethodDelete, "/api/v1/contents/5?mode=replace&with=six"},
+		{http.MethodGet, "/api/v1/contents/abc/usages"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s = %d, want 400", tt.method, tt.target, w.Code)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DefaultPlaylistHandler struct {
+	service *service.DefaultPlaylistService
+}
+
+func NewDefaultPlaylistHandler(service *service.DefaultPlaylistService) *DefaultPlaylistHandler {
+	return &DefaultPlaylistHandler{service: service}
+}
+
+func (h *DefaultPlaylistHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/default-playlists")
+	{
+		group.POST("", h.Create)
+		group.GET("", h.GetAll)
+		group.GET("/:id", h.GetByID)
+		group.PUT("/:id", h.Update)
+		group.DELETE("/:id", h.Delete)
+	}
+}
+
+// POST /default-playlists
+func (h *DefaultPlaylistHandler) Create(c *gin.Context) {
+	var playlist model.DefaultPlaylist
+	if err := c.ShouldBindJSON(&playlist); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.Create(&playlist); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, playlist)
+}
+
+// GET /default-playlists
+func (h *DefaultPlaylistHandler) GetAll(c *gin.Context) {
+	playlists, err := h.service.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playlists)
+}
+
+// GET /default-playlists/:id
+func (h *DefaultPlaylistHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	playlist, err := h.service.GetByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "default playlist not found"})
+		return
+	}
+	c.JSON(http.StatusOK, playlist)
+}
+
+// PUT /default-playlists/:id
+func (h *DefaultPlaylistHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var playlist model.DefaultPlaylist
+	if err := c.ShouldBindJSON(&playlist); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	playlist.ID = uint(id)
+	if err := h.service.Update(&playlist); err != nil {
+		c.JSON(defaultPlaylistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playlist)
+}
+
+// DELETE /default-playlists/:id
+func (h *DefaultPlaylistHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.service.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// defaultPlaylistErrorStatus: неизвестный плейлист — 404, остальное — ошибки запроса
+func defaultPlaylistErrorStatus(err error) int {
+	if errors.Is(err, service.ErrDefaultPlaylistNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExpiryHandler struct {
+	service *service.ExpiryService
+}
+
+func NewExpiryHandler(service *service.ExpiryService) *ExpiryHandler {
+	return &ExpiryHandler{service: service}
+}
+
+func (h *ExpiryHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/contents/expiring", h.Report)
+}
+
+// GET /contents/expiring?days=7
+// Контент, который перестанет показываться в ближайшие days дней, и блоки расписаний, которые опустеют
+func (h *ExpiryHandler) Report(c *gin.Context) {
+	days := service.DefaultExpiryWindow
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > service.MaxExpiryWindow {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 0 and " + strconv.Itoa(service.MaxExpiryWindow)})
+			return
+		}
+		days = n
+	}
+	report, err := h.service.Report(time.Now(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeedHandler отдаёт плеерам закэшированные записи RSS/Atom-лент
+type FeedHandler struct {
+	service *service.FeedService
+}
+
+func NewFeedHandler(service *service.FeedService) *FeedHandler {
+	return &FeedHandler{service: service}
+}
+
+func (h *FeedHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/contents")
+	{
+		group.GET("/:id/feed", h.GetItems)
+		group.POST("/:id/feed/refresh", h.Refresh)
+	}
+}
+
+// GET /contents/:id/feed
+func (h *FeedHandler) GetItems(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	view, err := h.service.GetItems(uint(id))
+	if err != nil {
+		c.JSON(feedErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	// записи обновляются не чаще раза в минуту — плееры и прокси могут кэшировать ответ
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, view)
+}
+
+// POST /contents/:id/feed/refresh — загрузить ленту немедленно
+func (h *FeedHandler) Refresh(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	view, err := h.service.Refresh(uint(id))
+	if err != nil {
+		status := feedErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadGateway // ошибка загрузки у издателя
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+func feedErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNotFeed):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrContentNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FolderHandler struct {
+	service *service.FolderService
+}
+
+func NewFolderHandler(service *service.FolderService) *FolderHandler {
+	return &FolderHandler{service: service}
+}
+
+func (h *FolderHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/folders")
+	{
+		group.POST("", h.Create)
+		group.GET("", h.GetAll)
+		group.GET("/:id", h.GetByID)
+		group.PUT("/:id", h.Update)
+		group.DELETE("/:id", h.Delete)
+	}
+}
+
+// POST /folders
+func (h *FolderHandler) Create(c *gin.Context) {
+	var folder model.Folder
+	if err := c.ShouldBindJSON(&folder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	folder.ID = 0
+	if err := h.service.Create(&folder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, folder)
+}
+
+// GET /folders — плоский список, дерево строится по parentId
+func (h *FolderHandler) GetAll(c *gin.Context) {
+	folders, err := h.service.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, folders)
+}
+
+// GET /folders/:id
+func (h *FolderHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	folder, err := h.service.GetByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "folder not found"})
+		return
+	}
+	c.JSON(http.StatusOK, folder)
+}
+
+// PUT /folders/:id — переименование и перемещение
+func (h *FolderHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var folder model.Folder
+	if err := c.ShouldBindJSON(&folder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	folder.ID = uint(id)
+	if err := h.service.Update(&folder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, folder)
+}
+
+// DELETE /folders/:id — подпапки и контент переходят в родительскую папку
+func (h *FolderHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.service.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userHeader — имя пользователя, от которого выполняется запрос. Авторизацию выполняет
+// прокси перед API, он же проставляет заголовок; без него действие считается анонимным.
+const userHeader = "X-User"
+
+// rolesHeader — роли пользователя через запятую, их тоже проставляет прокси
+const rolesHeader = "X-User-Roles"
+
+func currentUser(c *gin.Context) string {
+	return strings.TrimSpace(c.GetHeader(userHeader))
+}
+
+// currentReviewer — текущий пользователь вместе с ролями
+func currentReviewer(c *gin.Context) service.Reviewer {
+	r := service.Reviewer{Name: currentUser(c)}
+	for _, role := range strings.Split(c.GetHeader(rolesHeader), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			r.Roles = append(r.Roles, role)
+		}
+	}
+	return r
+}
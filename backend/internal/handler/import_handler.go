@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	service *service.ImportService
+}
+
+func NewImportHandler(service *service.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+func (h *ImportHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/contents/import", h.Import)
+}
+
+// POST /contents/import (multipart: archive — ZIP, manifest — manifest.json или .csv, folderId, locationId)
+// Манифест можно положить и в корень архива. Отвечает отчётом по каждому файлу.
+func (h *ImportHandler) Import(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxArchiveSize()+1<<20)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := service.ImportOptions{UploadedBy: currentUser(c)}
+	var archive *os.File
+	var size int64
+	defer func() {
+		if archive != nil {
+			archive.Close()
+			os.Remove(archive.Name())
+		}
+	}()
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(uploadStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+			return
+		}
+		switch part.FormName() {
+		case "archive":
+			if archive != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "only one archive is allowed"})
+				return
+			}
+			if archive, err = os.CreateTemp("", "import-*.zip"); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if size, err = io.Copy(archive, part); err != nil {
+				c.JSON(uploadStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+				return
+			}
+		case "manifest":
+			// читаем на байт больше предела, чтобы не обрезать манифест молча
+			limit := h.service.MaxManifestSize()
+			if opts.Manifest, err = io.ReadAll(io.LimitReader(part, limit+1)); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if int64(len(opts.Manifest)) > limit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("manifest is larger than %d bytes", limit)})
+				return
+			}
+			opts.ManifestName = part.FileName()
+		case "folderId", "locationId":
+			value, _ := io.ReadAll(io.LimitReader(part, 32))
+			id, err := strconv.ParseUint(string(value), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + part.FormName()})
+				return
+			}
+			ownerID := uint(id)
+			if part.FormName() == "folderId" {
+				opts.FolderID = &ownerID
+			} else {
+				opts.LocationID = &ownerID
+			}
+		}
+	}
+	if archive == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "archive is required"})
+		return
+	}
+	if size > h.service.MaxArchiveSize() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrFileTooLarge.Error()})
+		return
+	}
+
+	report, err := h.service.Import(archive, size, opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidArchive) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
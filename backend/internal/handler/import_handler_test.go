@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestImportRejectsOversizedManifest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	imports := service.NewImportService(nil, 10<<20)
+	NewImportHandler(imports).RegisterRoutes(r.Group("/api/v1"))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("manifest", "manifest.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(strings.Repeat("a", int(imports.MaxManifestSize())+1)))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/contents/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "manifest is larger") {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaHandler отдаёт объекты хранилища по подписанным ссылкам (Local.Presign)
+type MediaHandler struct {
+	store  storage.Storage
+	secret string
+}
+
+func NewMediaHandler(store storage.Storage, secret string) *MediaHandler {
+	return &MediaHandler{store: store, secret: secret}
+}
+
+func (h *MediaHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/media/*key", h.Get)
+	rg.HEAD("/media/*key", h.Get)
+}
+
+// GET|HEAD /media/*key?expires=...&signature=...
+func (h *MediaHandler) Get(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if h.secret == "" || !storage.VerifySignature(h.secret, key, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid or expired signature"})
+		return
+	}
+
+	obj, err := h.store.Get(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer obj.Close()
+
+	if strings.HasPrefix(key, "media/") {
+		// файлы контента хранятся под своим SHA-256 — это и есть строгий ETag
+		c.Header("ETag", `"`+key[strings.LastIndex(key, "/")+1:]+`"`)
+	}
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, obj)
+}
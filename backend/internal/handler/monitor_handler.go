@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	"github.com/TryHanger/digital_signage/backend/internal/service"
@@ -21,7 +23,7 @@ func (h *MonitorHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	group := rg.Group("/monitors")
 	group.GET("", h.GetAll)
 	group.POST("", h.Create)
-
+	group.PUT("/:id/resolution", h.SetResolution)
 }
 
 func (h *MonitorHandler) GetAll(c *gin.Context) {
@@ -40,8 +42,37 @@ func (h *MonitorHandler) Create(c *gin.Context) {
 		return
 	}
 	if err := h.service.CreateMonitor(&monitor); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(monitorErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, monitor)
 }
+
+// PUT /monitors/:id/resolution {"width": 1080, "height": 1920} — разрешение экрана для подгонки изображений
+func (h *MonitorHandler) SetResolution(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	monitor, err := h.service.SetResolution(uint(id), req.Width, req.Height)
+	if err != nil {
+		c.JSON(monitorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, monitor)
+}
+
+func monitorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidMonitor):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrMonitorNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
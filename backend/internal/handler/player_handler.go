@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlayerHandler struct {
+	service *service.PlayerService
+}
+
+func NewPlayerHandler(service *service.PlayerService) *PlayerHandler {
+	return &PlayerHandler{service: service}
+}
+
+func (h *PlayerHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/player")
+	{
+		group.GET("/:token/playback", h.GetPlayback)
+	}
+}
+
+// GET /player/:token/playback?at=2025-01-01T10:00:00+03:00
+func (h *PlayerHandler) GetPlayback(c *gin.Context) {
+	at := time.Now()
+	if raw := c.Query("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid at, expected RFC3339"})
+			return
+		}
+		at = parsed
+	}
+
+	playback, err := h.service.GetPlayback(c.Param("token"), at)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playback)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlaylistHandler struct {
+	service *service.PlaylistService
+}
+
+func NewPlaylistHandler(service *service.PlaylistService) *PlaylistHandler {
+	return &PlaylistHandler{service: service}
+}
+
+func (h *PlaylistHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/playlists")
+	{
+		group.POST("", h.Create)
+		group.GET("", h.GetAll)
+		group.GET("/:id", h.GetByID)
+		group.GET("/:id/usages", h.Usages)
+		group.PUT("/:id", h.Update)
+		group.DELETE("/:id", h.Delete)
+	}
+}
+
+// POST /playlists
+func (h *PlaylistHandler) Create(c *gin.Context) {
+	var playlist model.Playlist
+	if err := c.ShouldBindJSON(&playlist); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.Create(&playlist); err != nil {
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, playlist)
+}
+
+// GET /playlists
+func (h *PlaylistHandler) GetAll(c *gin.Context) {
+	playlists, err := h.service.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playlists)
+}
+
+// GET /playlists/:id
+func (h *PlaylistHandler) GetByID(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	playlist, err := h.service.GetByID(uint(id))
+	if err != nil {
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playlist)
+}
+
+// GET /playlists/:id/usages — блоки шаблонов и расписаний, ссылающиеся на плейлист
+func (h *PlaylistHandler) Usages(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	usages, err := h.service.Usages(uint(id))
+	if err != nil {
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usages)
+}
+
+// PUT /playlists/:id — элементы заменяются целиком; блоки со ссылкой сразу видят изменения
+func (h *PlaylistHandler) Update(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var playlist model.Playlist
+	if err := c.ShouldBindJSON(&playlist); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	playlist.ID = uint(id)
+	if err := h.service.Update(&playlist); err != nil {
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playlist)
+}
+
+// DELETE /playlists/:id — 409 со списком ссылок, пока на плейлист ссылаются блоки (link)
+func (h *PlaylistHandler) Delete(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	err := h.service.Delete(uint(id))
+	if errors.Is(err, service.ErrPlaylistInUse) {
+		usages, usagesErr := h.service.Usages(uint(id))
+		if usagesErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": usagesErr.Error()})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "usages": usages})
+		return
+	}
+	if err != nil {
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func playlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidPlaylist):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPlaylistNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPlaylistInUse):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RenditionHandler struct {
+	service *service.RenditionService
+}
+
+func NewRenditionHandler(service *service.RenditionService) *RenditionHandler {
+	return &RenditionHandler{service: service}
+}
+
+func (h *RenditionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/contents/:id/rendition", h.Rendition)
+}
+
+// GET /contents/:id/rendition?w=1920&h=1080&mode=fit|fill|crop&sig=...
+// Изображение, подогнанное под экран; без mode — режим подгонки контента.
+// Плееры получают подписанную ссылку в Playback, если у монитора задано разрешение.
+func (h *RenditionHandler) Rendition(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	width, errW := strconv.Atoi(c.Query("w"))
+	height, errH := strconv.Atoi(c.Query("h"))
+	if errW != nil || errH != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "w and h are required"})
+		return
+	}
+	rendition, err := h.service.Open(uint(id), width, height, c.Query("mode"), c.Query("sig"))
+	if errors.Is(err, service.ErrRenditionSignature) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(contentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer rendition.File.Close()
+
+	// имя в кэше включает SHA-256 файла и параметры подгонки, поэтому рендишен неизменен
+	c.Header("ETag", rendition.ETag)
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("Content-Type", "image/jpeg")
+	http.ServeContent(c.Writer, c.Request, "", rendition.ModTime, rendition.File)
+}
@@ -36,7 +36,7 @@ func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "Schedule created successfully"})
+	c.JSON(http.StatusCreated, gin.H{"message": "Schedule created successfully", "warnings": schedule.Warnings})
 }
 
 func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StorageHandler struct {
+	service *service.StorageService
+}
+
+func NewStorageHandler(service *service.StorageService) *StorageHandler {
+	return &StorageHandler{service: service}
+}
+
+func (h *StorageHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/storage")
+	{
+		group.GET("/usage", h.Usage)
+		group.GET("/largest", h.Largest)
+		group.GET("/unused", h.Unused)
+	}
+}
+
+// GET /storage/usage — занятое место и квоты по локациям
+func (h *StorageHandler) Usage(c *gin.Context) {
+	usage, err := h.service.Usage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// GET /storage/largest?locationId=&limit= — контент с самыми большими файлами
+func (h *StorageHandler) Largest(c *gin.Context) {
+	h.list(c, h.service.Largest)
+}
+
+// GET /storage/unused?locationId=&limit= — контент, который нигде не используется
+func (h *StorageHandler) Unused(c *gin.Context) {
+	h.list(c, h.service.Unused)
+}
+
+func (h *StorageHandler) list(c *gin.Context, find func(locationID *uint, limit int) ([]model.Content, error)) {
+	var locationID *uint
+	if v := c.Query("locationId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid locationId"})
+			return
+		}
+		lid := uint(id)
+		locationID = &lid
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	contents, err := find(locationID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, contents)
+}
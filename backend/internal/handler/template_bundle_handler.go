@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TemplateBundleHandler struct {
+	service *service.TemplateBundleService
+}
+
+func NewTemplateBundleHandler(service *service.TemplateBundleService) *TemplateBundleHandler {
+	return &TemplateBundleHandler{service: service}
+}
+
+func (h *TemplateBundleHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/templates")
+	{
+		group.GET("/:id/export", h.Export)
+		group.POST("/import", h.Import)
+	}
+}
+
+// GET /templates/:id/export?format=json|zip&media=true
+// media=true adds the uploaded files and implies the ZIP format;
+// 404 when one of them is missing from storage.
+func (h *TemplateBundleHandler) Export(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	withMedia := c.Query("media") == "true"
+	format := c.Query("format")
+	if format == "" {
+		format = "json"
+		if withMedia {
+			format = "zip"
+		}
+	}
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+		return
+	}
+	if format == "json" && withMedia {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "media files can only be exported in the zip format"})
+		return
+	}
+
+	bundle, err := h.service.Export(uint(id))
+	if err != nil {
+		c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if withMedia {
+		if err := h.service.CheckMedia(bundle); err != nil {
+			c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.Header("Content-Disposition", contentDisposition("attachment", fmt.Sprintf("template-%d.%s", id, format)))
+	if format == "json" {
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	// headers are already sent: the error can only go to the request log
+	if err := h.service.WriteZip(c.Writer, bundle, withMedia); err != nil {
+		c.Error(err)
+	}
+}
+
+// POST /templates/import?name=...
+// Bundle — JSON body, or a multipart form with the JSON or ZIP bundle in the "bundle" field.
+// On conflicts nothing is imported: 409 with the report listing them.
+func (h *TemplateBundleHandler) Import(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxBundleSize()+1<<20)
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("bundle")
+		if err != nil {
+			c.JSON(uploadStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	tmp, err := os.CreateTemp("", "template-bundle-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	size, err := io.Copy(tmp, body)
+	if err != nil {
+		c.JSON(uploadStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	if size > h.service.MaxBundleSize() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrFileTooLarge.Error()})
+		return
+	}
+
+	report, err := h.service.Import(tmp, size, service.BundleImportOptions{
+		Name:       c.Query("name"),
+		UploadedBy: currentUser(c),
+	})
+	if err != nil {
+		resp := gin.H{"error": err.Error()}
+		if report != nil {
+			resp["report"] = report
+		}
+		c.JSON(bundleErrorStatus(err), resp)
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+func bundleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidBundle):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTemplateNotFound), errors.Is(err, service.ErrBundleMediaNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrBundleConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+)
+
+func TestBundleErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: bad json", service.ErrInvalidBundle), http.StatusBadRequest},
+		{fmt.Errorf("%w: content 1", service.ErrBundleMediaNotFound), http.StatusNotFound},
+		{service.ErrBundleConflict, http.StatusConflict},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := bundleErrorStatus(tt.err); got != tt.want {
+			t.Errorf("bundleErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
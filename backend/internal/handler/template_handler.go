@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -26,8 +27,13 @@ func (h *TemplateHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		group.GET("", h.GetAll)
 		group.GET("/:id", h.GetByID)
 		group.POST("", h.CreateTemplate)
+		group.POST("/validate", h.Validate)
 		group.PUT("/:id", h.UpdateTemplate)
 		group.DELETE("/:id", h.Delete)
+		group.GET("/:id/versions", h.Versions)
+		group.GET("/:id/versions/diff", h.DiffVersions)
+		group.GET("/:id/versions/:version", h.GetVersion)
+		group.GET("/:id/outdated-schedules", h.OutdatedSchedules)
 
 	}
 }
@@ -42,7 +48,7 @@ func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "template created"})
+	c.JSON(http.StatusCreated, gin.H{"message": "template created", "version": template.Version, "warnings": template.Warnings})
 }
 
 func (h *TemplateHandler) GetAll(c *gin.Context) {
@@ -67,7 +73,30 @@ func (h *TemplateHandler) GetByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, template)
+	report, err := h.service.Validate(template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, struct {
+		*model.Template
+		Validation *service.ValidationReport `json:"validation"`
+	}{template, report})
+}
+
+// POST /templates/validate — checks a template without saving it and reports every problem found
+func (h *TemplateHandler) Validate(c *gin.Context) {
+	var template model.Template
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := h.service.Validate(&template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
 }
 
 func (h *TemplateHandler) Delete(c *gin.Context) {
@@ -104,5 +133,68 @@ func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "template updated"})
+	c.JSON(http.StatusOK, gin.H{"message": "template updated", "version": template.Version, "warnings": template.Warnings})
+}
+
+// GET /templates/:id/versions
+func (h *TemplateHandler) Versions(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	versions, err := h.service.Versions(uint(id))
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// GET /templates/:id/versions/:version — version with its snapshot
+func (h *TemplateHandler) GetVersion(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+	v, err := h.service.GetVersion(uint(id), version)
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// GET /templates/:id/versions/diff?from=1&to=2
+func (h *TemplateHandler) DiffVersions(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to versions are required"})
+		return
+	}
+	changes, err := h.service.DiffVersions(uint(id), from, to)
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "changes": changes})
+}
+
+// GET /templates/:id/outdated-schedules — schedules created from an older template version
+func (h *TemplateHandler) OutdatedSchedules(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	schedules, err := h.service.OutdatedSchedules(uint(id))
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+func templateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTemplateNotFound), errors.Is(err, service.ErrTemplateVersionNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
 }
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TryHanger/digital_signage/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tusVersion = "1.0.0"
+
+// UploadHandler — возобновляемые загрузки по протоколу tus 1.0.0
+// (расширения creation, expiration, termination, checksum)
+type UploadHandler struct {
+	service *service.UploadService
+}
+
+func NewUploadHandler(service *service.UploadService) *UploadHandler {
+	return &UploadHandler{service: service}
+}
+
+func (h *UploadHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	group := rg.Group("/contents/uploads")
+	group.Use(h.tusHeaders)
+	{
+		group.POST("", h.Create)
+		group.HEAD("/:uploadId", h.Head)
+		group.GET("/:uploadId", h.Get)
+		group.PATCH("/:uploadId", h.Patch)
+		group.DELETE("/:uploadId", h.Delete)
+	}
+}
+
+func (h *UploadHandler) tusHeaders(c *gin.Context) {
+	if v := c.GetHeader("Tus-Resumable"); v != "" && v != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatus(http.StatusPreconditionFailed)
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", "creation,expiration,termination,checksum")
+	c.Header("Tus-Checksum-Algorithm", "sha256")
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.service.MaxSize(), 10))
+	c.Next()
+}
+
+// POST /contents/uploads
+// Upload-Length: размер файла; Upload-Metadata: filename, title, description, duration, checksum (sha256 hex)
+func (h *UploadHandler) Create(c *gin.Context) {
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length header is required"})
+		return
+	}
+	metadata, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upload, err := h.service.Create(length, metadata, currentUser(c))
+	if err != nil {
+		if errors.Is(err, service.ErrFileTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusInsufficientStorage, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+upload.ID)
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// HEAD /contents/uploads/:uploadId — текущее смещение
+func (h *UploadHandler) Head(c *gin.Context) {
+	upload, err := h.service.Get(c.Param("uploadId"))
+	if err != nil {
+		c.Status(uploadErrorStatus(err))
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+// GET /contents/uploads/:uploadId — состояние загрузки в JSON (в т.ч. contentId после завершения)
+func (h *UploadHandler) Get(c *gin.Context) {
+	upload, err := h.service.Get(c.Param("uploadId"))
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, upload)
+}
+
+// PATCH /contents/uploads/:uploadId — дозапись части с Upload-Offset
+func (h *UploadHandler) Patch(c *gin.Context) {
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+	checksum, err := parseUploadChecksum(c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upload, err := h.service.Append(c.Param("uploadId"), offset, c.Request.Body, checksum)
+	if upload != nil {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		if upload.ContentID != nil {
+			c.Header("Content-Location", "/api/v1/contents/"+strconv.FormatUint(uint64(*upload.ContentID), 10))
+		}
+	}
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /contents/uploads/:uploadId
+func (h *UploadHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Param("uploadId")); err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrOffsetMismatch), errors.Is(err, service.ErrUploadFinished):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrChecksumMismatch):
+		return 460 // tus: Checksum Mismatch
+	case errors.Is(err, service.ErrChecksumAlgorithm), errors.Is(err, service.ErrInvalidContent):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUploadTooLarge), errors.Is(err, service.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseUploadMetadata разбирает "key base64value,key2 base64value2"
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("invalid Upload-Metadata value for " + key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// parseUploadChecksum разбирает "sha256 base64digest"
+func parseUploadChecksum(header string) (*service.ChunkChecksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, ok := strings.Cut(header, " ")
+	if !ok {
+		return nil, errors.New("invalid Upload-Checksum header")
+	}
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid Upload-Checksum header")
+	}
+	return &service.ChunkChecksum{Algorithm: algorithm, Sum: sum}, nil
+}
@@ -0,0 +1,119 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// probeImage читает размеры через image.DecodeConfig; у JPEG учитывается EXIF-ориентация
+func probeImage(r io.ReaderAt, size int64, mime string) (*Info, error) {
+	cfg, _, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Width: cfg.Width, Height: cfg.Height, Codec: mime[len("image/"):]}
+	if mime == "image/jpeg" {
+		if o := jpegOrientation(r, size); o >= 5 && o <= 8 {
+			// EXIF 5..8 — кадр повёрнут на 90°/270°
+			info.Width, info.Height = info.Height, info.Width
+		}
+	}
+	return info, nil
+}
+
+// jpegOrientation ищет тег Orientation (0x0112) в EXIF-блоке APP1; 0 — не найден
+func jpegOrientation(r io.ReaderAt, size int64) int {
+	buf := make([]byte, 4)
+	for pos := int64(2); pos+4 <= size; {
+		if _, err := r.ReadAt(buf, pos); err != nil || buf[0] != 0xFF {
+			return 0
+		}
+		marker := buf[1]
+		length := int64(binary.BigEndian.Uint16(buf[2:4]))
+		if marker == 0xDA || marker == 0xD9 { // начало данных изображения
+			return 0
+		}
+		if marker == 0xE1 && length > 8 {
+			segment := make([]byte, length-2)
+			if _, err := r.ReadAt(segment, pos+4); err != nil {
+				return 0
+			}
+			if o := exifOrientation(segment); o != 0 {
+				return o
+			}
+		}
+		pos += 2 + length
+	}
+	return 0
+}
+
+func exifOrientation(segment []byte) int {
+	if len(segment) < 14 || string(segment[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := segment[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 0
+}
+
+// probeWebP разбирает заголовок RIFF/WEBP (форматы VP8, VP8L и VP8X)
+func probeWebP(r io.ReaderAt) (*Info, error) {
+	buf := make([]byte, 30)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, err
+	}
+	if string(buf[0:4]) != "RIFF" || string(buf[8:12]) != "WEBP" {
+		return nil, errors.New("media: invalid webp header")
+	}
+	info := &Info{Codec: "webp"}
+	chunk := buf[20:]
+	switch string(buf[12:16]) {
+	case "VP8 ":
+		// 3 байта frame tag, стартовый код 9d 01 2a, затем 14-битные ширина и высота
+		if chunk[3] != 0x9d || chunk[4] != 0x01 || chunk[5] != 0x2a {
+			return nil, errors.New("media: invalid vp8 frame")
+		}
+		info.Width = int(binary.LittleEndian.Uint16(chunk[6:8]) & 0x3FFF)
+		info.Height = int(binary.LittleEndian.Uint16(chunk[8:10]) & 0x3FFF)
+	case "VP8L":
+		if chunk[0] != 0x2f {
+			return nil, errors.New("media: invalid vp8l signature")
+		}
+		bits := binary.LittleEndian.Uint32(chunk[1:5])
+		info.Width = int(bits&0x3FFF) + 1
+		info.Height = int((bits>>14)&0x3FFF) + 1
+	case "VP8X":
+		info.Width = int(uint32(chunk[4])|uint32(chunk[5])<<8|uint32(chunk[6])<<16) + 1
+		info.Height = int(uint32(chunk[7])|uint32(chunk[8])<<8|uint32(chunk[9])<<16) + 1
+	default:
+		return nil, errors.New("media: unknown webp chunk")
+	}
+	return info, nil
+}
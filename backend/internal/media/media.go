@@ -0,0 +1,69 @@
+// Package media извлекает метаданные медиафайлов (длительность, размеры, кодек)
+// без внешних утилит: разбирает заголовки контейнеров и изображений на чистом Go.
+package media
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+)
+
+var ErrUnsupported = errors.New("media: unsupported format")
+
+// Ориентация кадра
+const (
+	OrientationLandscape = "landscape"
+	OrientationPortrait  = "portrait"
+	OrientationSquare    = "square"
+)
+
+// Info — извлечённые метаданные
+type Info struct {
+	Duration    float64 // секунды, 0 для изображений
+	Width       int
+	Height      int
+	Codec       string
+	Orientation string
+}
+
+// DurationSeconds возвращает длительность, округлённую вверх до целых секунд
+func (i *Info) DurationSeconds() int {
+	return int(math.Ceil(i.Duration))
+}
+
+// Probe разбирает файл по MIME-типу, определённому при загрузке
+func Probe(r io.ReaderAt, size int64, mime string) (*Info, error) {
+	var info *Info
+	var err error
+	switch {
+	case mime == "video/mp4", mime == "video/quicktime", mime == "video/x-m4v", strings.HasPrefix(mime, "video/3gpp"):
+		info, err = probeMP4(r, size)
+	case mime == "video/webm", mime == "video/x-matroska":
+		info, err = probeWebM(r, size)
+	case mime == "image/webp":
+		info, err = probeWebP(r)
+	case mime == "image/jpeg", mime == "image/png", mime == "image/gif":
+		info, err = probeImage(r, size, mime)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.Orientation = orientation(info.Width, info.Height)
+	return info, nil
+}
+
+func orientation(width, height int) string {
+	switch {
+	case width == 0 || height == 0:
+		return ""
+	case width > height:
+		return OrientationLandscape
+	case width < height:
+		return OrientationPortrait
+	default:
+		return OrientationSquare
+	}
+}
@@ -0,0 +1,230 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// boxHeader — заголовок атома ISO BMFF (MP4/MOV)
+type boxHeader struct {
+	typ         string
+	start, size int64 // смещение и размер содержимого (без заголовка)
+}
+
+// readBoxes перечисляет атомы в диапазоне [start, end)
+func readBoxes(r io.ReaderAt, start, end int64) ([]boxHeader, error) {
+	var boxes []boxHeader
+	buf := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err := r.ReadAt(buf[:8], pos); err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(buf[:4]))
+		typ := string(buf[4:8])
+		header := int64(8)
+		switch size {
+		case 0: // до конца файла
+			size = end - pos
+		case 1: // 64-битный размер
+			if _, err := r.ReadAt(buf[8:16], pos+8); err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(buf[8:16]))
+			header = 16
+		}
+		if size < header || pos+size > end {
+			return nil, errors.New("media: corrupt mp4 box " + typ)
+		}
+		boxes = append(boxes, boxHeader{typ: typ, start: pos + header, size: size - header})
+		pos += size
+	}
+	return boxes, nil
+}
+
+func findBox(boxes []boxHeader, typ string) *boxHeader {
+	for i := range boxes {
+		if boxes[i].typ == typ {
+			return &boxes[i]
+		}
+	}
+	return nil
+}
+
+func readBox(r io.ReaderAt, box *boxHeader, max int64) ([]byte, error) {
+	n := box.size
+	if n > max {
+		n = max
+	}
+	buf := make([]byte, n)
+	_, err := r.ReadAt(buf, box.start)
+	return buf, err
+}
+
+// probeMP4: длительность из moov/mvhd, размеры и поворот из tkhd видеодорожки, кодек из stsd
+func probeMP4(r io.ReaderAt, size int64) (*Info, error) {
+	top, err := readBoxes(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	moov := findBox(top, "moov")
+	if moov == nil {
+		return nil, errors.New("media: mp4 without moov atom")
+	}
+	children, err := readBoxes(r, moov.start, moov.start+moov.size)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	if mvhd := findBox(children, "mvhd"); mvhd != nil {
+		data, err := readBox(r, mvhd, 32)
+		if err != nil {
+			return nil, err
+		}
+		info.Duration = mvhdDuration(data)
+	}
+
+	for _, trak := range children {
+		if trak.typ != "trak" {
+			continue
+		}
+		video, err := probeTrack(r, trak, info)
+		if err != nil {
+			return nil, err
+		}
+		if video {
+			break
+		}
+	}
+	return info, nil
+}
+
+func mvhdDuration(data []byte) float64 {
+	if len(data) < 20 {
+		return 0
+	}
+	if data[0] == 1 && len(data) >= 32 {
+		timescale := binary.BigEndian.Uint32(data[20:24])
+		duration := binary.BigEndian.Uint64(data[24:32])
+		if timescale == 0 {
+			return 0
+		}
+		return float64(duration) / float64(timescale)
+	}
+	timescale := binary.BigEndian.Uint32(data[12:16])
+	duration := binary.BigEndian.Uint32(data[16:20])
+	if timescale == 0 {
+		return 0
+	}
+	return float64(duration) / float64(timescale)
+}
+
+// probeTrack заполняет info из видеодорожки; возвращает false, если дорожка не видео
+func probeTrack(r io.ReaderAt, trak boxHeader, info *Info) (bool, error) {
+	boxes, err := readBoxes(r, trak.start, trak.start+trak.size)
+	if err != nil {
+		return false, err
+	}
+	mdia := findBox(boxes, "mdia")
+	if mdia == nil {
+		return false, nil
+	}
+	mdiaBoxes, err := readBoxes(r, mdia.start, mdia.start+mdia.size)
+	if err != nil {
+		return false, err
+	}
+	hdlr := findBox(mdiaBoxes, "hdlr")
+	if hdlr == nil {
+		return false, nil
+	}
+	data, err := readBox(r, hdlr, 12)
+	if err != nil || len(data) < 12 || string(data[8:12]) != "vide" {
+		return false, err
+	}
+
+	if tkhd := findBox(boxes, "tkhd"); tkhd != nil {
+		data, err := readBox(r, tkhd, 96)
+		if err != nil {
+			return false, err
+		}
+		info.Width, info.Height = tkhdSize(data)
+	}
+
+	// mdia/minf/stbl/stsd — первая запись описания сэмплов содержит fourcc кодека
+	if minf := findBox(mdiaBoxes, "minf"); minf != nil {
+		minfBoxes, err := readBoxes(r, minf.start, minf.start+minf.size)
+		if err != nil {
+			return false, err
+		}
+		if stbl := findBox(minfBoxes, "stbl"); stbl != nil {
+			stblBoxes, err := readBoxes(r, stbl.start, stbl.start+stbl.size)
+			if err != nil {
+				return false, err
+			}
+			if stsd := findBox(stblBoxes, "stsd"); stsd != nil {
+				data, err := readBox(r, stsd, 16)
+				if err != nil {
+					return false, err
+				}
+				if len(data) >= 16 {
+					info.Codec = codecName(string(data[12:16]))
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+// tkhdSize читает размеры кадра (16.16) с учётом матрицы поворота;
+// для слишком короткого атома возвращает 0, 0
+func tkhdSize(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	offset := 0
+	switch data[0] {
+	case 0:
+		offset = 4 + 20
+	case 1:
+		offset = 4 + 32
+	default:
+		return 0, 0
+	}
+	offset += 8 + 2 + 2 + 2 + 2 // reserved, layer, alternate_group, volume, reserved
+	if len(data) < offset+36+8 {
+		return 0, 0
+	}
+	matrix := data[offset : offset+36]
+	width := int(binary.BigEndian.Uint32(data[offset+36:offset+40]) >> 16)
+	height := int(binary.BigEndian.Uint32(data[offset+40:offset+44]) >> 16)
+
+	// поворот на 90/270: a == 0, b == ±1
+	a := int32(binary.BigEndian.Uint32(matrix[0:4]))
+	b := int32(binary.BigEndian.Uint32(matrix[4:8]))
+	if a == 0 && (b == 0x10000 || b == -0x10000) {
+		width, height = height, width
+	}
+	return width, height
+}
+
+func codecName(fourcc string) string {
+	switch fourcc {
+	case "avc1", "avc3":
+		return "h264"
+	case "hvc1", "hev1":
+		return "hevc"
+	case "vp08":
+		return "vp8"
+	case "vp09":
+		return "vp9"
+	case "av01":
+		return "av1"
+	case "mp4v":
+		return "mpeg4"
+	case "apcn", "apch", "apcs", "apco", "ap4h":
+		return "prores"
+	default:
+		return fourcc
+	}
+}
@@ -0,0 +1,52 @@
+package media
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// tkhd собирает содержимое атома tkhd заданной версии
+func tkhd(version byte, matrix [9]int32, width, height int) []byte {
+	data := []byte{version, 0, 0, 0}
+	if version == 1 {
+		data = append(data, make([]byte, 32)...)
+	} else {
+		data = append(data, make([]byte, 20)...)
+	}
+	data = append(data, make([]byte, 16)...)
+	for _, v := range matrix {
+		data = binary.BigEndian.AppendUint32(data, uint32(v))
+	}
+	data = binary.BigEndian.AppendUint32(data, uint32(width)<<16)
+	return binary.BigEndian.AppendUint32(data, uint32(height)<<16)
+}
+
+func TestTkhdSize(t *testing.T) {
+	identity := [9]int32{0x10000, 0, 0, 0, 0x10000, 0, 0, 0, 0x40000000}
+	rotated := [9]int32{0, 0x10000, 0, -0x10000, 0, 0, 0, 0, 0x40000000}
+	v0 := tkhd(0, identity, 1920, 1080)
+	v1 := tkhd(1, identity, 1280, 720)
+
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+	}{
+		{"version 0", v0, 1920, 1080},
+		{"version 1", v1, 1280, 720},
+		{"rotated", tkhd(0, rotated, 1920, 1080), 1080, 1920},
+		{"empty box", nil, 0, 0},
+		{"version only", []byte{1}, 0, 0},
+		{"truncated version 0", v0[:len(v0)-1], 0, 0},
+		{"truncated version 1", v1[:len(v1)-1], 0, 0},
+		{"unknown version", append([]byte{2}, v0[1:]...), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := tkhdSize(tt.data)
+			if w != tt.width || h != tt.height {
+				t.Fatalf("size = %dx%d, want %dx%d", w, h, tt.width, tt.height)
+			}
+		})
+	}
+}
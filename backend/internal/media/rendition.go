@@ -0,0 +1,106 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+)
+
+// Режимы подгонки изображения под экран
+const (
+	RenditionFit  = "fit"  // целиком внутри кадра, без полей: одна из сторон может быть меньше
+	RenditionFill = "fill" // целиком внутри кадра, свободное место заполняется фоном
+	RenditionCrop = "crop" // кадр заполнен полностью, лишнее обрезается вокруг точки фокуса
+)
+
+// MaxRenditionSide — наибольшая сторона рендишена (8K)
+const MaxRenditionSide = 7680
+
+// RenditionOptions — целевой размер и способ подгонки. FocusX, FocusY — точка,
+// которая должна остаться в кадре при обрезке, в долях ширины и высоты (0.5 — центр).
+type RenditionOptions struct {
+	Width  int
+	Height int
+	Mode   string
+	FocusX float64
+	FocusY float64
+}
+
+// ValidRenditionMode проверяет режим подгонки
+func ValidRenditionMode(mode string) bool {
+	return mode == RenditionFit || mode == RenditionFill || mode == RenditionCrop
+}
+
+// Rendition декодирует изображение (с учётом EXIF-ориентации у JPEG) и приводит его
+// к размеру экрана. В отличие от превью, изображение может и увеличиваться. Результат — JPEG.
+func Rendition(r io.ReaderAt, size int64, opts RenditionOptions) ([]byte, error) {
+	if opts.Width <= 0 || opts.Height <= 0 || opts.Width > MaxRenditionSide || opts.Height > MaxRenditionSide {
+		return nil, fmt.Errorf("media: invalid rendition size %dx%d", opts.Width, opts.Height)
+	}
+	if !ValidRenditionMode(opts.Mode) {
+		return nil, fmt.Errorf("media: unknown rendition mode %q", opts.Mode)
+	}
+	src, err := decodeImage(r, size)
+	if err != nil {
+		return nil, err
+	}
+	rgba := toRGBA(src)
+	if o := jpegOrientation(r, size); o > 1 {
+		rgba = orient(rgba, o)
+	}
+
+	var dst *image.RGBA
+	switch opts.Mode {
+	case RenditionCrop:
+		dst = scale(cropToAspect(rgba, opts), opts.Width, opts.Height)
+	case RenditionFill:
+		dst = image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+		fitted := fitInto(rgba, opts.Width, opts.Height)
+		offset := image.Pt((opts.Width-fitted.Bounds().Dx())/2, (opts.Height-fitted.Bounds().Dy())/2)
+		draw.Draw(dst, fitted.Bounds().Add(offset), fitted, image.Point{}, draw.Src)
+	default:
+		dst = fitInto(rgba, opts.Width, opts.Height)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fitInto масштабирует кадр так, чтобы он целиком поместился в w×h
+func fitInto(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, sh*w/sw
+	if dh > h {
+		dw, dh = sw*h/sh, h
+	}
+	return scale(src, max(dw, 1), max(dh, 1))
+}
+
+// cropToAspect вырезает из кадра наибольшую область с пропорциями экрана,
+// по возможности с точкой фокуса в центре
+func cropToAspect(src *image.RGBA, opts RenditionOptions) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	cw, ch := sw, sw*opts.Height/opts.Width
+	if ch > sh {
+		cw, ch = sh*opts.Width/opts.Height, sh
+	}
+	cw, ch = max(cw, 1), max(ch, 1)
+	x0 := clamp(int(opts.FocusX*float64(sw))-cw/2, 0, sw-cw)
+	y0 := clamp(int(opts.FocusY*float64(sh))-ch/2, 0, sh-ch)
+
+	dst := image.NewRGBA(image.Rect(0, 0, cw, ch))
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+func clamp(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
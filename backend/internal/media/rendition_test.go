@@ -0,0 +1,37 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image/jpeg"
+	"testing"
+)
+
+func TestRendition(t *testing.T) {
+	data := encodePNG(t, 800, 400)
+	for mode, want := range map[string][2]int{
+		RenditionFit:  {400, 200},
+		RenditionFill: {400, 400},
+		RenditionCrop: {400, 400},
+	} {
+		out, err := Rendition(bytes.NewReader(data), int64(len(data)), RenditionOptions{Width: 400, Height: 400, Mode: mode, FocusX: 0.5, FocusY: 0.5})
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if cfg.Width != want[0] || cfg.Height != want[1] {
+			t.Fatalf("%s: %dx%d, want %dx%d", mode, cfg.Width, cfg.Height, want[0], want[1])
+		}
+	}
+}
+
+func TestRenditionRejectsHugeImages(t *testing.T) {
+	data := hugePNG(t)
+	opts := RenditionOptions{Width: 100, Height: 100, Mode: RenditionFit}
+	if _, err := Rendition(bytes.NewReader(data), int64(len(data)), opts); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("err = %v, want ErrImageTooLarge", err)
+	}
+}
@@ -0,0 +1,155 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+)
+
+// Размеры превью: наибольшая сторона в пикселях
+var ThumbnailSizes = map[string]int{
+	"small":  160,
+	"medium": 320,
+	"large":  640,
+}
+
+// DefaultThumbnailSize — размер превью, если он не указан в запросе
+const DefaultThumbnailSize = "medium"
+
+// MaxImagePixels — наибольшее число пикселей декодируемого изображения (≈ 8K × 6K).
+// Декодированный кадр занимает 4 байта на пиксель, а заголовок PNG может объявить
+// 65535×65535 при файле в несколько килобайт.
+const MaxImagePixels = 50_000_000
+
+var ErrImageTooLarge = errors.New("media: image is too large")
+
+// Thumbnails декодирует изображение (с учётом EXIF-ориентации у JPEG) и возвращает
+// превью всех размеров из ThumbnailSizes в формате JPEG
+func Thumbnails(r io.ReaderAt, size int64) (map[string][]byte, error) {
+	src, err := decodeImage(r, size)
+	if err != nil {
+		return nil, err
+	}
+	rgba := toRGBA(src)
+	if o := jpegOrientation(r, size); o > 1 {
+		rgba = orient(rgba, o)
+	}
+
+	result := make(map[string][]byte, len(ThumbnailSizes))
+	for name, side := range ThumbnailSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(rgba, side), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		result[name] = buf.Bytes()
+	}
+	return result, nil
+}
+
+// decodeImage проверяет размеры по заголовку и только потом декодирует кадр
+func decodeImage(r io.ReaderAt, size int64) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(io.NewSectionReader(r, 0, size))
+	return src, err
+}
+
+// toRGBA переводит изображение в RGBA на белом фоне (у JPEG нет прозрачности)
+func toRGBA(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// orient поворачивает/отражает кадр согласно EXIF Orientation (2..8)
+func orient(src *image.RGBA, o int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// resize уменьшает кадр так, чтобы наибольшая сторона не превышала side.
+// Изображения не увеличиваются.
+func resize(src *image.RGBA, side int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= side && h <= side {
+		return src
+	}
+	dw, dh := side, h*side/w
+	if h > w {
+		dw, dh = w*side/h, side
+	}
+	return scale(src, max(dw, 1), max(dh, 1))
+}
+
+// scale приводит кадр к размеру dw×dh, усредняя пиксели исходной области (box filter);
+// при увеличении каждый пиксель берётся из ближайшего исходного
+func scale(src *image.RGBA, dw, dh int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,62 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	img.Set(0, 0, color.Black)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestThumbnails(t *testing.T) {
+	data := encodePNG(t, 800, 400)
+	thumbs, err := Thumbnails(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, side := range ThumbnailSizes {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumbs[name]))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.Width != side || cfg.Height != side/2 {
+			t.Fatalf("%s: %dx%d, want %dx%d", name, cfg.Width, cfg.Height, side, side/2)
+		}
+	}
+}
+
+// hugePNG — PNG в пару сотен байт, заголовок которого объявляет 20000×20000
+func hugePNG(t *testing.T) []byte {
+	t.Helper()
+	data := encodePNG(t, 1, 1)
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], 20000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 20000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	return data
+}
+
+func TestThumbnailsRejectsHugeImages(t *testing.T) {
+	data := hugePNG(t)
+	if _, err := Thumbnails(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("err = %v, want ErrImageTooLarge", err)
+	}
+}
@@ -0,0 +1,283 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Идентификаторы элементов EBML (WebM/Matroska)
+const (
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+	ebmlTracks        = 0x1654AE6B
+	ebmlTrackEntry    = 0xAE
+	ebmlTrackType     = 0x83
+	ebmlCodecID       = 0x86
+	ebmlVideo         = 0xE0
+	ebmlPixelWidth    = 0xB0
+	ebmlPixelHeight   = 0xBA
+	ebmlCluster       = 0x1F43B675
+
+	ebmlUnknownSize = -1
+	trackTypeVideo  = 1
+)
+
+type ebmlElement struct {
+	id          uint32
+	start, size int64 // смещение и размер данных; size == ebmlUnknownSize — до конца родителя
+}
+
+// readVint читает целое переменной длины EBML; keepMarker — для идентификаторов элементов
+func readVint(r io.ReaderAt, pos int64, keepMarker bool) (uint64, int, error) {
+	first := make([]byte, 1)
+	if _, err := r.ReadAt(first, pos); err != nil {
+		return 0, 0, err
+	}
+	length := 1
+	for mask := byte(0x80); length <= 8 && first[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, 0, errors.New("media: invalid ebml vint")
+	}
+	buf := make([]byte, length)
+	if _, err := r.ReadAt(buf, pos); err != nil {
+		return 0, 0, err
+	}
+	if !keepMarker {
+		buf[0] &= byte(0xFF >> length)
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v, length, nil
+}
+
+func readElement(r io.ReaderAt, pos int64) (ebmlElement, int64, error) {
+	id, idLen, err := readVint(r, pos, true)
+	if err != nil {
+		return ebmlElement{}, 0, err
+	}
+	size, sizeLen, err := readVint(r, pos+int64(idLen), false)
+	if err != nil {
+		return ebmlElement{}, 0, err
+	}
+	el := ebmlElement{id: uint32(id), start: pos + int64(idLen+sizeLen), size: int64(size)}
+	if size == (uint64(1)<<(7*uint(sizeLen)))-1 {
+		el.size = ebmlUnknownSize
+	}
+	return el, el.start, nil
+}
+
+// children перечисляет дочерние элементы в [start, end), останавливаясь на первом кластере
+func children(r io.ReaderAt, start, end int64) ([]ebmlElement, error) {
+	var elements []ebmlElement
+	for pos := start; pos < end; {
+		el, dataStart, err := readElement(r, pos)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if el.id == ebmlCluster {
+			break
+		}
+		if el.size == ebmlUnknownSize {
+			el.size = end - dataStart
+		}
+		// размер из файла не должен выводить за родителя (а значит, и за файл):
+		// иначе повреждённый файл заставит выделить под элемент гигабайты
+		if dataStart > end || el.size > end-dataStart {
+			return nil, errors.New("media: ebml element exceeds its parent")
+		}
+		elements = append(elements, el)
+		pos = dataStart + el.size
+	}
+	return elements, nil
+}
+
+func readUint(r io.ReaderAt, el ebmlElement) (uint64, error) {
+	if el.size > 8 {
+		return 0, errors.New("media: ebml uint too long")
+	}
+	buf := make([]byte, el.size)
+	if _, err := r.ReadAt(buf, el.start); err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+func readFloat(r io.ReaderAt, el ebmlElement) (float64, error) {
+	if el.size != 4 && el.size != 8 {
+		return 0, errors.New("media: invalid ebml float")
+	}
+	buf := make([]byte, el.size)
+	if _, err := r.ReadAt(buf, el.start); err != nil {
+		return 0, err
+	}
+	if el.size == 4 {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(buf))), nil
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(buf)), nil
+}
+
+func readString(r io.ReaderAt, el ebmlElement) (string, error) {
+	if el.size > 256 {
+		return "", errors.New("media: ebml string too long")
+	}
+	buf := make([]byte, el.size)
+	if _, err := r.ReadAt(buf, el.start); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		if b == 0 {
+			buf = buf[:i]
+			break
+		}
+	}
+	return string(buf), nil
+}
+
+// probeWebM: Segment/Info даёт длительность, Segment/Tracks — кодек и размеры видеодорожки
+func probeWebM(r io.ReaderAt, size int64) (*Info, error) {
+	top, err := children(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	var segment *ebmlElement
+	for i := range top {
+		if top[i].id == ebmlSegment {
+			segment = &top[i]
+		}
+	}
+	if segment == nil {
+		return nil, errors.New("media: webm without segment")
+	}
+	elements, err := children(r, segment.start, segment.start+segment.size)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	for _, el := range elements {
+		switch el.id {
+		case ebmlInfo:
+			if err := webmInfo(r, el, info); err != nil {
+				return nil, err
+			}
+		case ebmlTracks:
+			if err := webmTracks(r, el, info); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return info, nil
+}
+
+func webmInfo(r io.ReaderAt, infoEl ebmlElement, info *Info) error {
+	elements, err := children(r, infoEl.start, infoEl.start+infoEl.size)
+	if err != nil {
+		return err
+	}
+	scale := uint64(1000000) // по умолчанию миллисекунды
+	var duration float64
+	for _, el := range elements {
+		switch el.id {
+		case ebmlTimecodeScale:
+			if scale, err = readUint(r, el); err != nil {
+				return err
+			}
+		case ebmlDuration:
+			if duration, err = readFloat(r, el); err != nil {
+				return err
+			}
+		}
+	}
+	info.Duration = duration * float64(scale) / 1e9
+	return nil
+}
+
+func webmTracks(r io.ReaderAt, tracks ebmlElement, info *Info) error {
+	entries, err := children(r, tracks.start, tracks.start+tracks.size)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.id != ebmlTrackEntry {
+			continue
+		}
+		fields, err := children(r, entry.start, entry.start+entry.size)
+		if err != nil {
+			return err
+		}
+		var trackType uint64
+		var codec string
+		var width, height uint64
+		for _, f := range fields {
+			switch f.id {
+			case ebmlTrackType:
+				trackType, err = readUint(r, f)
+			case ebmlCodecID:
+				codec, err = readString(r, f)
+			case ebmlVideo:
+				width, height, err = webmVideo(r, f)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if trackType == trackTypeVideo {
+			info.Codec = matroskaCodec(codec)
+			info.Width, info.Height = int(width), int(height)
+			return nil
+		}
+	}
+	return nil
+}
+
+func webmVideo(r io.ReaderAt, video ebmlElement) (uint64, uint64, error) {
+	fields, err := children(r, video.start, video.start+video.size)
+	if err != nil {
+		return 0, 0, err
+	}
+	var width, height uint64
+	for _, f := range fields {
+		switch f.id {
+		case ebmlPixelWidth:
+			width, err = readUint(r, f)
+		case ebmlPixelHeight:
+			height, err = readUint(r, f)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return width, height, nil
+}
+
+func matroskaCodec(id string) string {
+	switch id {
+	case "V_VP8":
+		return "vp8"
+	case "V_VP9":
+		return "vp9"
+	case "V_AV1":
+		return "av1"
+	case "V_MPEG4/ISO/AVC":
+		return "h264"
+	case "V_MPEGH/ISO/HEVC":
+		return "hevc"
+	default:
+		return id
+	}
+}
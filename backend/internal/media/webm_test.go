@@ -0,0 +1,79 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// ebml собирает элемент с 8-байтовым размером
+func ebml(id []byte, data ...[]byte) []byte {
+	body := bytes.Join(data, nil)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(body)))
+	size[0] = 0x01
+	return append(append(append([]byte{}, id...), size...), body...)
+}
+
+func ebmlUint(id []byte, v uint16) []byte {
+	return ebml(id, binary.BigEndian.AppendUint16(nil, v))
+}
+
+func ebmlFloat(id []byte, v float64) []byte {
+	return ebml(id, binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+}
+
+var (
+	idSegment     = []byte{0x18, 0x53, 0x80, 0x67}
+	idInfo        = []byte{0x15, 0x49, 0xA9, 0x66}
+	idDuration    = []byte{0x44, 0x89}
+	idTracks      = []byte{0x16, 0x54, 0xAE, 0x6B}
+	idTrackEntry  = []byte{0xAE}
+	idTrackType   = []byte{0x83}
+	idCodecID     = []byte{0x86}
+	idVideo       = []byte{0xE0}
+	idPixelWidth  = []byte{0xB0}
+	idPixelHeight = []byte{0xBA}
+)
+
+func probeBytes(data []byte) (*Info, error) {
+	return probeWebM(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestProbeWebM(t *testing.T) {
+	file := ebml(idSegment,
+		ebml(idInfo, ebmlFloat(idDuration, 12500)),
+		ebml(idTracks, ebml(idTrackEntry,
+			ebmlUint(idTrackType, trackTypeVideo),
+			ebml(idCodecID, []byte("V_VP9")),
+			ebml(idVideo, ebmlUint(idPixelWidth, 1920), ebmlUint(idPixelHeight, 1080)),
+		)),
+	)
+	info, err := probeBytes(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Duration != 12.5 || info.Codec != "vp9" || info.Width != 1920 || info.Height != 1080 {
+		t.Fatalf("info = %+v", info)
+	}
+}
+
+func TestProbeWebMRejectsCorruptSizes(t *testing.T) {
+	// размер элемента Duration — почти 2^56 байт, данных нет
+	huge := append(append([]byte{}, idDuration...), 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE)
+	tests := map[string][]byte{
+		"element larger than file":   ebml(idSegment, ebml(idInfo, huge)),
+		"float of invalid size":      ebml(idSegment, ebml(idInfo, ebml(idDuration, []byte{1, 2, 3}))),
+		"child larger than parent":   ebml(idSegment, ebml(idInfo, ebmlFloat(idDuration, 1)[:10])),
+		"uint longer than eight":     ebml(idSegment, ebml(idTracks, ebml(idTrackEntry, ebml(idTrackType, make([]byte, 9))))),
+		"string longer than allowed": ebml(idSegment, ebml(idTracks, ebml(idTrackEntry, ebml(idCodecID, make([]byte, 300))))),
+	}
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := probeBytes(file); err == nil {
+				t.Fatal("corrupt file parsed without error")
+			}
+		})
+	}
+}
@@ -1,14 +1,108 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Виды контента; правила для каждого вида — в service/content_kinds.go
+const (
+	ContentTypeImage  = "image"
+	ContentTypeVideo  = "video"
+	ContentTypeWeb    = "web"    // веб-страница по URL
+	ContentTypeStream = "stream" // прямая трансляция (HLS, RTSP, RTMP...)
+	ContentTypeHTML5  = "html5"  // HTML5-пакет (zip) или ссылка на его index.html
+	ContentTypeText   = "text"   // текстовое сообщение
+	ContentTypeFeed   = "feed"   // RSS/Atom-лента, записи кэшируются на сервере
+)
+
+// Состояние доступности URL веб-страниц и трансляций (проверяет HealthChecker)
+const (
+	ContentHealthy   = "healthy"
+	ContentUnhealthy = "unhealthy"
+)
+
+// Состояние согласования контента
+const (
+	ReviewDraft    = "draft"
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
 
 type Content struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Title       string    `json:"title" gorm:"not null"`
-	Type        string    `json:"type"`
-	Path        string    `json:"path"`
-	Description string    `json:"description"`
-	Duration    int       `json:"duration"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Title       string `json:"title" gorm:"not null"`
+	Type        string `json:"type"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+	Duration    int    `json:"duration"`
+	Body        string `json:"body,omitempty" gorm:"type:text"` // текст для вида text
+
+	// Локация-владелец: её квота учитывает файлы контента; nil — общий контент
+	LocationID *uint     `json:"locationId" gorm:"index"`
+	Location   *Location `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+
+	// Библиотека: папка и свободные теги (в нижнем регистре)
+	FolderID *uint          `json:"folderId" gorm:"index"`
+	Folder   *Folder        `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	Tags     pq.StringArray `json:"tags" gorm:"type:text[]"`
+
+	// Согласование: в эфир попадает только одобренный контент.
+	// Строки, созданные до появления согласования, получают статус approved.
+	ReviewStatus  string     `json:"reviewStatus" gorm:"index;default:approved"` // draft | pending | approved | rejected
+	SubmittedBy   string     `json:"submittedBy,omitempty"`
+	ReviewedBy    string     `json:"reviewedBy,omitempty"`
+	ReviewComment string     `json:"reviewComment,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+
+	// Период показа (лицензия, акция): вне его контент не попадает в плейлисты
+	ValidFrom  *time.Time `json:"validFrom,omitempty" gorm:"type:date;index"`
+	ValidUntil *time.Time `json:"validUntil,omitempty" gorm:"type:date;index"` // включительно
+
+	// Загруженный файл (хранится под именем, равным SHA-256); Version — номер текущей ContentVersion
+	Version  int    `json:"version,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Checksum string `json:"checksum,omitempty" gorm:"index;size:64"`
+	MimeType string `json:"mimeType,omitempty"`
+
+	// Извлечено из файла при загрузке
+	MediaDuration int    `json:"mediaDuration,omitempty"` // секунды
+	Width         int    `json:"width,omitempty"`
+	Height        int    `json:"height,omitempty"`
+	Orientation   string `json:"orientation,omitempty"` // landscape | portrait | square
+	Codec         string `json:"codec,omitempty"`
+
+	// Подгонка изображения под разрешение монитора: fit | fill | crop (пусто — fit).
+	// FocusX, FocusY — точка, остающаяся в кадре при crop, в долях ширины и высоты; nil — центр
+	FitMode string   `json:"fitMode,omitempty"`
+	FocusX  *float64 `json:"focusX,omitempty"`
+	FocusY  *float64 `json:"focusY,omitempty"`
+
+	// Веб-страницы, трансляции и ленты
+	RefreshInterval int        `json:"refreshInterval,omitempty"` // секунды: перезагрузка страницы / обновление ленты
+	Zoom            float64    `json:"zoom,omitempty"`            // масштаб страницы, 1 — 100%
+	HealthStatus    string     `json:"healthStatus,omitempty"`    // healthy | unhealthy, пусто — ещё не проверялся
+	HealthError     string     `json:"healthError,omitempty"`
+	HealthCheckedAt *time.Time `json:"healthCheckedAt,omitempty"`
+
+	// Ленты RSS/Atom
+	FeedMaxItems  int        `json:"feedMaxItems,omitempty"` // 0 — по умолчанию
+	FeedFilter    string     `json:"feedFilter,omitempty"`   // слова через запятую, "-слово" исключает
+	FeedFetchedAt *time.Time `json:"feedFetchedAt,omitempty"`
+
+	// SHA-256 источника превью: самого изображения или постера видео
+	Thumbnail string `json:"thumbnail,omitempty" gorm:"size:64"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// EffectiveDuration — длительность показа: заданная вручную, иначе извлечённая из файла
+func (c *Content) EffectiveDuration() int {
+	if c.Duration > 0 {
+		return c.Duration
+	}
+	return c.MediaDuration
 }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// ContentVersion — файл, который был у контента: при замене файла старые версии
+// сохраняются, и к любой из них можно откатиться
+type ContentVersion struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	ContentID uint `json:"contentId" gorm:"not null;uniqueIndex:idx_content_version"`
+	Version   int  `json:"version" gorm:"not null;uniqueIndex:idx_content_version"`
+
+	Size          int64  `json:"size"`
+	Checksum      string `json:"checksum" gorm:"index;size:64"`
+	MimeType      string `json:"mimeType"`
+	MediaDuration int    `json:"mediaDuration,omitempty"`
+	Width         int    `json:"width,omitempty"`
+	Height        int    `json:"height,omitempty"`
+	Orientation   string `json:"orientation,omitempty"`
+	Codec         string `json:"codec,omitempty"`
+	Thumbnail     string `json:"thumbnail,omitempty" gorm:"size:64"`
+
+	UploadedBy   string `json:"uploadedBy,omitempty"`
+	RestoredFrom *int   `json:"restoredFrom,omitempty"` // версия, к которой откатились
+
+	CreatedAt time.Time `json:"createdAt"`
+}
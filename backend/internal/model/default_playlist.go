@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// ========== УРОВНИ ПЛЕЙЛИСТОВ ПО УМОЛЧАНИЮ ==========
+type DefaultLevel string
+
+const (
+	DefaultLevelGlobal   DefaultLevel = "global"
+	DefaultLevelLocation DefaultLevel = "location"
+	DefaultLevelGroup    DefaultLevel = "group"
+	DefaultLevelMonitor  DefaultLevel = "monitor"
+)
+
+// DefaultPlaylist — контент, который монитор показывает, когда ни одно расписание не покрывает текущее время.
+// Берётся самый специфичный: монитор > группа > локация > глобальный.
+type DefaultPlaylist struct {
+	ID    uint         `json:"id" gorm:"primaryKey"`
+	Name  string       `json:"name"`
+	Level DefaultLevel `json:"level" gorm:"not null"`
+
+	// Цель, в зависимости от Level
+	LocationID *uint `json:"locationId,omitempty"`
+	GroupID    *uint `json:"groupId,omitempty"`
+	MonitorID  *uint `json:"monitorId,omitempty"`
+
+	Items []DefaultPlaylistItem `json:"items" gorm:"foreignKey:PlaylistID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type DefaultPlaylistItem struct {
+	ID         uint     `json:"id" gorm:"primaryKey"`
+	PlaylistID uint     `json:"playlistId" gorm:"not null"`
+	ContentID  uint     `json:"contentId" gorm:"not null"`
+	Content    *Content `json:"content,omitempty"`
+	Position   int      `json:"position"`
+	Duration   *int     `json:"duration,omitempty"`
+}
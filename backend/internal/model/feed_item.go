@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// FeedItem — запись RSS/Atom-ленты контента вида feed, закэшированная на сервере
+type FeedItem struct {
+	ID          uint       `json:"-" gorm:"primaryKey"`
+	ContentID   uint       `json:"-" gorm:"index;not null"`
+	GUID        string     `json:"guid"`
+	Title       string     `json:"title"`
+	Summary     string     `json:"summary"`
+	Link        string     `json:"link,omitempty"`
+	ImageURL    string     `json:"imageUrl,omitempty"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	Position    int        `json:"-"` // порядок в ленте: 0 — самая свежая запись
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Folder — папка библиотеки контента; ParentID == nil у папок верхнего уровня
+type Folder struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	ParentID  *uint     `json:"parentId" gorm:"index"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -4,4 +4,7 @@ type Location struct {
 	ID       uint      `json:"id" gorm:"primary_key"`
 	Name     string    `json:"name"`
 	Monitors []Monitor `json:"monitors" gorm:"foreignKey:LocationID"`
+
+	// Квота на файлы контента локации в байтах: 0 — квота по умолчанию из конфигурации
+	StorageQuota int64 `json:"storageQuota"`
 }
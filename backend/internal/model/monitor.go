@@ -3,10 +3,13 @@ package model
 import "time"
 
 type Monitor struct {
-	ID         uint          `json:"id" gorm:"primaryKey"`
-	Name       string        `json:"name" gorm:"not null"`
-	Token      string        `json:"token" gorm:"uniqueIndex;size:32;not null"`
-	Status     string        `json:"status"`
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Name   string `json:"name" gorm:"not null"`
+	Token  string `json:"token" gorm:"uniqueIndex;size:32;not null"`
+	Status string `json:"status"`
+	// Заявленное разрешение экрана; по нему изображения подгоняются под монитор (0 — не задано)
+	Width      int           `json:"width"`
+	Height     int           `json:"height"`
 	CreatedAt  time.Time     `json:"createdAt"`
 	LocationID uint          `json:"locationID"`
 	Location   *Location     `json:"location" gorm:"constraint:OnDelete:CASCADE"`
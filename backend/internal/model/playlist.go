@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ========== ССЫЛКА НА ПЛЕЙЛИСТ ==========
+type PlaylistMode string
+
+const (
+	PlaylistLink     PlaylistMode = "link"     // блок показывает текущие элементы плейлиста
+	PlaylistSnapshot PlaylistMode = "snapshot" // элементы плейлиста скопированы в блок при сохранении
+)
+
+// Playlist — именованный упорядоченный список контента, на который ссылаются
+// блоки шаблонов и расписаний вместо собственных копий элементов
+type Playlist struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description,omitempty"`
+	Items       []PlaylistItem `json:"items" gorm:"foreignKey:PlaylistID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type PlaylistItem struct {
+	ID         uint     `json:"id" gorm:"primaryKey"`
+	PlaylistID uint     `json:"playlistId" gorm:"not null;index"`
+	ContentID  uint     `json:"contentId" gorm:"not null"`
+	Content    *Content `json:"content,omitempty"`
+	Position   int      `json:"position"`
+	Duration   *int     `json:"duration,omitempty"` // пусто — длительность контента
+	Zone       string   `json:"zone,omitempty"`     // область раскладки шаблона; пусто — основная
+
+	// Параметры ротации и окно действия — как у элемента блока расписания.
+	// Блоки шаблонов не поддерживают окна: такой плейлист в шаблон не подключить.
+	Weight       int           `json:"weight,omitempty"`
+	PlaysPerHour int           `json:"playsPerHour,omitempty"`
+	ValidFrom    *time.Time    `json:"validFrom,omitempty" gorm:"type:date"`
+	ValidUntil   *time.Time    `json:"validUntil,omitempty" gorm:"type:date"`
+	Weekdays     pq.Int64Array `json:"weekdays,omitempty" gorm:"type:integer[]"`
+	StartTime    string        `json:"startTime,omitempty"`
+	EndTime      string        `json:"endTime,omitempty"`
+}
+
+// HasWindow сообщает, ограничен ли показ элемента датами, днями недели или временем
+func (it PlaylistItem) HasWindow() bool {
+	return it.ValidFrom != nil || it.ValidUntil != nil || len(it.Weekdays) > 0 || it.StartTime != "" || it.EndTime != ""
+}
@@ -14,6 +14,15 @@ const (
 	RepeatWeekly RepeatType = "weekly"
 )
 
+// ========== РОТАЦИЯ ==========
+type RotationMode string
+
+const (
+	RotationSequential RotationMode = "sequential" // по порядку Position
+	RotationWeighted   RotationMode = "weighted"   // доля эфира пропорционально Weight
+	RotationFrequency  RotationMode = "frequency"  // PlaysPerHour + Weight как % эфира, остальное заполняют прочие
+)
+
 // ========== ДНИ НЕДЕЛИ ==========
 const (
 	Monday    = 1
@@ -34,6 +43,8 @@ type Schedule struct {
 	// Шаблон (для копирования блоков)
 	TemplateID uint      `json:"templateId" gorm:"not null"`
 	Template   *Template `json:"template,omitempty"`
+	// Версия шаблона, из которой расписание создано; меньше Template.Version — расписание отстало
+	TemplateVersion int `json:"templateVersion"`
 
 	// Устройства: либо Location, либо Group, либо отдельные Monitors
 	LocationID *uint         `json:"locationId,omitempty"`
@@ -57,6 +68,9 @@ type Schedule struct {
 	// Статус
 	IsActive bool `json:"isActive" gorm:"default:true"`
 
+	// Неодобренный контент в блоках (вычисляется, не хранится)
+	Warnings []string `json:"warnings,omitempty" gorm:"-"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -72,9 +86,17 @@ type ScheduleBlock struct {
 	EndTime   string `json:"endTime"`   // "22:00"
 	Position  int    `json:"position"`
 
+	// Ротация элементов внутри блока
+	RotationMode RotationMode `json:"rotationMode" gorm:"default:'sequential'"`
+
 	// Контент
 	Items []ScheduleBlockItem `json:"items" gorm:"foreignKey:BlockID"`
 
+	// Плейлист вместо собственных элементов: при link Items заполняются из него при чтении
+	PlaylistID   *uint        `json:"playlistId,omitempty"`
+	Playlist     *Playlist    `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	PlaylistMode PlaylistMode `json:"playlistMode,omitempty"` // link | snapshot
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -85,8 +107,23 @@ type ScheduleBlockItem struct {
 	ContentID uint     `json:"contentId" gorm:"not null"`
 	Content   *Content `json:"content,omitempty"`
 
-	Position int  `json:"position"`
-	Duration *int `json:"duration,omitempty"` // для фото
+	Position int    `json:"position"`
+	Duration *int   `json:"duration,omitempty"` // для фото
+	Zone     string `json:"zone,omitempty"`     // область раскладки шаблона; пусто — основная
+
+	// Параметры ротации
+	Weight       int `json:"weight,omitempty"`       // weighted: относительный вес; frequency: % эфира
+	PlaysPerHour int `json:"playsPerHour,omitempty"` // frequency: сколько раз в час
+
+	// Окно действия элемента внутри блока
+	ValidFrom  *time.Time    `json:"validFrom,omitempty" gorm:"type:date"`
+	ValidUntil *time.Time    `json:"validUntil,omitempty" gorm:"type:date"` // включительно
+	Weekdays   pq.Int64Array `json:"weekdays,omitempty" gorm:"type:integer[]"`
+	StartTime  string        `json:"startTime,omitempty"` // "10:00", пусто — весь блок
+	EndTime    string        `json:"endTime,omitempty"`
+
+	// Элемент сейчас вне своего окна (вычисляется, не хранится)
+	Dormant bool `json:"dormant" gorm:"-"`
 
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -2,13 +2,30 @@ package model
 
 import "time"
 
+// Холст раскладки по умолчанию
+const (
+	DefaultCanvasWidth  = 1920
+	DefaultCanvasHeight = 1080
+)
+
 type Template struct {
 	ID          uint            `json:"id" gorm:"primaryKey"`
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
 	Blocks      []TemplateBlock `json:"blocks" gorm:"foreignKey:TemplateID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	CreatedAt   time.Time       `json:"createdAt"`
-	UpdatedAt   time.Time       `json:"updatedAt"`
+
+	// Раскладка экрана: холст и области на нём. Без областей шаблон — один полноэкранный цикл.
+	CanvasWidth  int            `json:"canvasWidth"`
+	CanvasHeight int            `json:"canvasHeight"`
+	Zones        []TemplateZone `json:"zones" gorm:"foreignKey:TemplateID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	// Номер последней версии: растёт при каждом сохранении с изменениями, снимки — в TemplateVersion
+	Version int `json:"version" gorm:"not null;default:1"`
+
+	// Неодобренный контент в блоках (вычисляется, не хранится)
+	Warnings  []string  `json:"warnings,omitempty" gorm:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 type TemplateBlock struct {
@@ -18,13 +35,36 @@ type TemplateBlock struct {
 	StartTime  time.Time         `json:"startTime"`
 	EndTime    time.Time         `json:"endTime"`
 	Contents   []TemplateContent `json:"contents" gorm:"foreignKey:BlockID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	// Плейлист вместо собственного контента: при link Contents заполняются из него при чтении
+	PlaylistID   *uint        `json:"playlistId,omitempty"`
+	Playlist     *Playlist    `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	PlaylistMode PlaylistMode `json:"playlistMode,omitempty"` // link | snapshot
 }
 
 type TemplateContent struct {
 	ID        uint   `json:"id" gorm:"primaryKey"`
 	BlockID   uint   `json:"blockId"`
 	ContentID uint   `json:"contentId"`
-	Type      string `json:"type"`
+	Type      string `json:"type"` // копируется из Content, значение клиента игнорируется
 	Order     int    `json:"order"`
 	Duration  int    `json:"duration"`
+	Zone      string `json:"zone,omitempty"` // имя области раскладки; пусто — основная область
+}
+
+// TemplateZone — область экрана со своим циклом контента (основное видео, промо сбоку,
+// бегущая строка, часы). Координаты — в пикселях холста шаблона; ZIndex — порядок наложения.
+// PlaylistID — собственный плейлист области: он играет, когда у текущего блока
+// нет элементов для этой области.
+type TemplateZone struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	TemplateID uint      `json:"templateId" gorm:"index"`
+	Name       string    `json:"name" gorm:"not null"`
+	X          int       `json:"x"`
+	Y          int       `json:"y"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	ZIndex     int       `json:"zIndex"`
+	PlaylistID *uint     `json:"playlistId,omitempty"`
+	Playlist   *Playlist `json:"-" gorm:"constraint:OnDelete:SET NULL"`
 }
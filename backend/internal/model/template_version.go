@@ -0,0 +1,16 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TemplateVersion — неизменяемый снимок шаблона: блоки, контент и раскладка на момент
+// сохранения. Каждое изменение шаблона создаёт новую версию, старые не переписываются.
+type TemplateVersion struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	TemplateID uint            `json:"templateId" gorm:"not null;uniqueIndex:idx_template_version"`
+	Version    int             `json:"version" gorm:"not null;uniqueIndex:idx_template_version"`
+	Snapshot   json.RawMessage `json:"snapshot,omitempty" gorm:"type:jsonb;not null"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Upload — незавершённая возобновляемая загрузка (протокол tus).
+// Данные копятся во временном файле; запись Content создаётся только после получения всех байт.
+type Upload struct {
+	ID     string `json:"id" gorm:"primaryKey;size:32"`
+	Length int64  `json:"length"`
+	Offset int64  `json:"offset"`
+
+	// Метаданные из Upload-Metadata
+	Filename    string `json:"filename"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Duration    int    `json:"duration,omitempty"`
+	Checksum    string `json:"checksum,omitempty"` // ожидаемый SHA-256 (hex), сверяется при завершении
+	UploadedBy  string `json:"uploadedBy,omitempty"`
+	LocationID  *uint  `json:"locationId,omitempty"`
+
+	// Заполняется после завершения загрузки
+	ContentID *uint `json:"contentId,omitempty"`
+
+	ExpiresAt time.Time `json:"expiresAt" gorm:"index"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
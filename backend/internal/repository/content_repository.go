@@ -1,8 +1,14 @@
 package repository
 
 import (
+	"strings"
+	"time"
+	"unicode"
+
 	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ContentRepository struct {
@@ -17,10 +23,162 @@ func (r *ContentRepository) Create(content *model.Content) error {
 	return r.db.Create(content).Error
 }
 
-func (r *ContentRepository) GetAll() ([]model.Content, error) {
+// Transaction выполняет fn с репозиторием, работающим внутри одной транзакции
+func (r *ContentRepository) Transaction(fn func(tx *ContentRepository) error) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		return fn(&ContentRepository{db: db})
+	})
+}
+
+// Storage — учёт места на том же подключении, в том числе внутри транзакции
+func (r *ContentRepository) Storage() *StorageRepository {
+	return &StorageRepository{db: r.db}
+}
+
+// Templates — шаблоны на том же подключении, в том числе внутри транзакции
+func (r *ContentRepository) Templates() *TemplateRepository {
+	return &TemplateRepository{db: r.db}
+}
+
+// Playlists — плейлисты на том же подключении, в том числе внутри транзакции
+func (r *ContentRepository) Playlists() *PlaylistRepository {
+	return &PlaylistRepository{db: r.db}
+}
+
+// GetByChecksum возвращает самый ранний контент с файлом checksum
+func (r *ContentRepository) GetByChecksum(checksum string) (*model.Content, error) {
+	var content model.Content
+	err := r.db.Where("checksum = ?", checksum).Order("id").First(&content).Error
+	return &content, err
+}
+
+// GetByTypeAndPath возвращает самый ранний контент вида contentType с адресом path
+func (r *ContentRepository) GetByTypeAndPath(contentType, path string) (*model.Content, error) {
+	var content model.Content
+	err := r.db.Where("type = ? AND path = ?", contentType, path).Order("id").First(&content).Error
+	return &content, err
+}
+
+// ContentFilter — параметры поиска по библиотеке контента
+type ContentFilter struct {
+	Query        string   // полнотекстовый поиск по названию и описанию
+	Types        []string // любой из видов
+	Tags         []string // все перечисленные теги
+	FolderID     *uint    // 0 — контент вне папок
+	Recursive    bool     // вместе с подпапками FolderID
+	LocationID   *uint    // 0 — общий контент без локации
+	UploadedFrom *time.Time
+	UploadedTo   *time.Time // не включительно
+	Orientation  string
+	ReviewStatus string
+	Offset       int
+	Limit        int
+}
+
+// TagCount — тег и количество контента с ним
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// Search возвращает страницу контента по фильтру и общее число найденного
+func (r *ContentRepository) Search(f ContentFilter) ([]model.Content, int64, error) {
+	q := r.db.Model(&model.Content{})
+	tsQuery := prefixTSQuery(f.Query)
+	if tsQuery != "" {
+		q = q.Where("search_vector @@ to_tsquery('simple', ?)", tsQuery)
+	}
+	if len(f.Types) > 0 {
+		q = q.Where("type IN ?", f.Types)
+	}
+	if len(f.Tags) > 0 {
+		q = q.Where("tags @> ?", pq.StringArray(f.Tags))
+	}
+	switch {
+	case f.FolderID == nil:
+	case *f.FolderID == 0:
+		q = q.Where("folder_id IS NULL")
+	case f.Recursive:
+		q = q.Where(`folder_id IN (
+			WITH RECURSIVE sub AS (
+				SELECT id FROM folders WHERE id = ?
+				UNION ALL
+				SELECT f.id FROM folders f JOIN sub ON f.parent_id = sub.id
+			) SELECT id FROM sub)`, *f.FolderID)
+	default:
+		q = q.Where("folder_id = ?", *f.FolderID)
+	}
+	switch {
+	case f.LocationID == nil:
+	case *f.LocationID == 0:
+		q = q.Where("location_id IS NULL")
+	default:
+		q = q.Where("location_id = ?", *f.LocationID)
+	}
+	if f.UploadedFrom != nil {
+		q = q.Where("created_at >= ?", *f.UploadedFrom)
+	}
+	if f.UploadedTo != nil {
+		q = q.Where("created_at < ?", *f.UploadedTo)
+	}
+	if f.Orientation != "" {
+		q = q.Where("orientation = ?", f.Orientation)
+	}
+	if f.ReviewStatus != "" {
+		q = q.Where("review_status = ?", f.ReviewStatus)
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// ORDER BY задаётся одним выражением: clause.OrderBy с Expression при слиянии
+	// с последующими Order заменяется ими, и сортировка по рангу терялась
+	if tsQuery != "" {
+		q = q.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(search_vector, to_tsquery('simple', ?)) DESC, created_at DESC, id DESC",
+			Vars: []interface{}{tsQuery},
+		}})
+	} else {
+		q = q.Order("created_at DESC").Order("id DESC")
+	}
 	var contents []model.Content
-	err := r.db.Find(&contents).Error
-	return contents, err
+	err := q.Offset(f.Offset).Limit(f.Limit).Find(&contents).Error
+	return contents, total, err
+}
+
+// Tags возвращает все теги библиотеки с количеством контента
+func (r *ContentRepository) Tags() ([]TagCount, error) {
+	var tags []TagCount
+	err := r.db.Raw(`SELECT tag, COUNT(*) AS count FROM contents, unnest(tags) AS tag GROUP BY tag ORDER BY tag`).Scan(&tags).Error
+	return tags, err
+}
+
+// FolderExists проверяет, что папка существует
+func (r *ContentRepository) FolderExists(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Folder{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// LocationExists проверяет, что локация существует
+func (r *ContentRepository) LocationExists(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Location{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// prefixTSQuery превращает строку поиска в запрос tsquery по префиксам слов:
+// "зим расп" -> "зим:* & расп:*". Спецсимволы tsquery отбрасываются.
+func prefixTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
 }
 
 func (r *ContentRepository) GetByID(id uint) (*model.Content, error) {
@@ -29,10 +187,228 @@ func (r *ContentRepository) GetByID(id uint) (*model.Content, error) {
 	return &content, err
 }
 
+// GetByTypes возвращает весь контент перечисленных видов
+func (r *ContentRepository) GetByTypes(types ...string) ([]model.Content, error) {
+	var contents []model.Content
+	err := r.db.Where("type IN ?", types).Find(&contents).Error
+	return contents, err
+}
+
+// UpdateReview сохраняет только состояние согласования контента
+func (r *ContentRepository) UpdateReview(content *model.Content) error {
+	return r.db.Model(content).
+		Select("review_status", "submitted_by", "reviewed_by", "review_comment", "reviewed_at", "updated_at").
+		Updates(content).Error
+}
+
+// GetExpiring возвращает контент, период показа которого заканчивается в днях [from, to]
+func (r *ContentRepository) GetExpiring(from, to time.Time) ([]model.Content, error) {
+	var contents []model.Content
+	err := r.db.Where("valid_until BETWEEN ? AND ?", from.Format("2006-01-02"), to.Format("2006-01-02")).Order("valid_until").Order("id").Find(&contents).Error
+	return contents, err
+}
+
+// UpdateHealth сохраняет результат проверки доступности, не трогая остальные поля и UpdatedAt
+func (r *ContentRepository) UpdateHealth(id uint, status, message string, checkedAt time.Time) error {
+	return r.db.Model(&model.Content{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"health_status":     status,
+		"health_error":      message,
+		"health_checked_at": checkedAt,
+	}).Error
+}
+
+// MarkFeedFetched отмечает успешную загрузку ленты
+func (r *ContentRepository) MarkFeedFetched(id uint, fetchedAt time.Time) error {
+	return r.db.Model(&model.Content{}).Where("id = ?", id).UpdateColumn("feed_fetched_at", fetchedAt).Error
+}
+
+// GetByIDs возвращает контент по списку ID, в виде map[id]
+func (r *ContentRepository) GetByIDs(ids []uint) (map[uint]model.Content, error) {
+	result := make(map[uint]model.Content, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var contents []model.Content
+	if err := r.db.Where("id IN ?", ids).Find(&contents).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range contents {
+		result[c.ID] = c
+	}
+	return result, nil
+}
+
+// Update сохраняет контент и переносит его вид в ссылающиеся на него элементы шаблонов
 func (r *ContentRepository) Update(content *model.Content) error {
-	return r.db.Save(content).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return saveContent(tx, content)
+	})
+}
+
+// UpdateWithVersion сохраняет контент вместе с новой версией его файла
+func (r *ContentRepository) UpdateWithVersion(content *model.Content, version *model.ContentVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveContent(tx, content); err != nil {
+			return err
+		}
+		return tx.Create(version).Error
+	})
+}
+
+// SaveNextVersion сохраняет существующий контент со следующей версией файла. Номер выдаёт
+// UPDATE ... RETURNING: он блокирует строку контента до конца транзакции, поэтому
+// одновременные замены файла получают разные номера, а не одну и ту же версию.
+func (r *ContentRepository) SaveNextVersion(content *model.Content, version *model.ContentVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var next int
+		if err := tx.Raw("UPDATE contents SET version = version + 1 WHERE id = ? RETURNING version", content.ID).Scan(&next).Error; err != nil {
+			return err
+		}
+		if next == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		content.Version, version.Version = next, next
+		if err := saveContent(tx, content); err != nil {
+			return err
+		}
+		return tx.Create(version).Error
+	})
+}
+
+func saveContent(tx *gorm.DB, content *model.Content) error {
+	if err := tx.Save(content).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.TemplateContent{}).
+		Where("content_id = ? AND type <> ?", content.ID, content.Type).
+		Update("type", content.Type).Error
 }
 
+// GetVersions возвращает версии файла контента, от новых к старым
+func (r *ContentRepository) GetVersions(contentID uint) ([]model.ContentVersion, error) {
+	versions := []model.ContentVersion{}
+	err := r.db.Where("content_id = ?", contentID).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+func (r *ContentRepository) GetVersion(contentID uint, version int) (*model.ContentVersion, error) {
+	var v model.ContentVersion
+	err := r.db.Where("content_id = ? AND version = ?", contentID, version).First(&v).Error
+	return &v, err
+}
+
+// SetVersionThumbnail меняет превью версии (постер назначается уже загруженному видео)
+func (r *ContentRepository) SetVersionThumbnail(contentID uint, version int, thumbnail string) error {
+	return r.db.Model(&model.ContentVersion{}).
+		Where("content_id = ? AND version = ?", contentID, version).
+		Update("thumbnail", thumbnail).Error
+}
+
+// ContentUsage — место, где используется контент: элемент блока шаблона или расписания
+// либо элемент плейлиста (именованного или по умолчанию)
+type ContentUsage struct {
+	Kind      string `json:"kind"` // template | schedule | playlist | default_playlist
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	BlockID   *uint  `json:"blockId,omitempty"`
+	BlockName string `json:"blockName,omitempty"`
+	ItemID    uint   `json:"itemId"`
+}
+
+// Usages возвращает все ссылки на контент из шаблонов, расписаний и плейлистов
+func (r *ContentRepository) Usages(id uint) ([]ContentUsage, error) {
+	usages := []ContentUsage{}
+	err := r.db.Raw(`
+		SELECT 'template' AS kind, t.id, t.name, b.id AS block_id, b.name AS block_name, i.id AS item_id
+		FROM template_contents i
+		JOIN template_blocks b ON b.id = i.block_id
+		JOIN templates t ON t.id = b.template_id
+		WHERE i.content_id = ?
+		UNION ALL
+		SELECT 'schedule', s.id, s.name, b.id, b.name, i.id
+		FROM schedule_block_items i
+		JOIN schedule_blocks b ON b.id = i.block_id
+		JOIN schedules s ON s.id = b.schedule_id
+		WHERE i.content_id = ?
+		UNION ALL
+		SELECT 'default_playlist', p.id, p.name, NULL, '', i.id
+		FROM default_playlist_items i
+		JOIN default_playlists p ON p.id = i.playlist_id
+		WHERE i.content_id = ?
+		UNION ALL
+		SELECT 'playlist', p.id, p.name, NULL, '', i.id
+		FROM playlist_items i
+		JOIN playlists p ON p.id = i.playlist_id
+		WHERE i.content_id = ?
+		ORDER BY kind, id, block_id, item_id`, id, id, id, id).Scan(&usages).Error
+	return usages, err
+}
+
+// ObjectInUse — файл или источник превью с этим SHA-256 ещё нужен какому-либо контенту
+// или сохранённой версии
+func (r *ContentRepository) ObjectInUse(checksum string) (bool, error) {
+	for _, m := range []interface{}{&model.Content{}, &model.ContentVersion{}} {
+		var count int64
+		err := r.db.Model(m).Where("checksum = ? OR thumbnail = ?", checksum, checksum).Count(&count).Error
+		if err != nil || count > 0 {
+			return count > 0, err
+		}
+	}
+	return false, nil
+}
+
+// Delete удаляет контент вместе со всеми ссылками на него
 func (r *ContentRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Content{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []interface{}{&model.TemplateContent{}, &model.ScheduleBlockItem{}, &model.DefaultPlaylistItem{}, &model.PlaylistItem{}} {
+			if err := tx.Where("content_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return deleteContent(tx, id)
+	})
+}
+
+// DeleteUnused удаляет контент, только если на него ничто не ссылается, и возвращает найденные
+// ссылки. Проверка и удаление идут в одной транзакции под блокировкой строки контента:
+// вставка элементов с внешним ключом на него ждёт конца транзакции.
+func (r *ContentRepository) DeleteUnused(id uint) ([]ContentUsage, error) {
+	var usages []ContentUsage
+	err := r.Transaction(func(tx *ContentRepository) error {
+		var content model.Content
+		if err := tx.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&content, id).Error; err != nil {
+			return err
+		}
+		var err error
+		if usages, err = tx.Usages(id); err != nil || len(usages) > 0 {
+			return err
+		}
+		return deleteContent(tx.db, id)
+	})
+	return usages, err
+}
+
+// ReplaceAndDelete переводит все ссылки на контент id на контент replacement и удаляет id
+func (r *ContentRepository) ReplaceAndDelete(id uint, replacement *model.Content) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.TemplateContent{}).Where("content_id = ?", id).
+			Updates(map[string]interface{}{"content_id": replacement.ID, "type": replacement.Type}).Error; err != nil {
+			return err
+		}
+		for _, m := range []interface{}{&model.ScheduleBlockItem{}, &model.DefaultPlaylistItem{}, &model.PlaylistItem{}} {
+			if err := tx.Model(m).Where("content_id = ?", id).Update("content_id", replacement.ID).Error; err != nil {
+				return err
+			}
+		}
+		return deleteContent(tx, id)
+	})
+}
+
+func deleteContent(tx *gorm.DB, id uint) error {
+	for _, m := range []interface{}{&model.FeedItem{}, &model.ContentVersion{}} {
+		if err := tx.Where("content_id = ?", id).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&model.Content{}, id).Error
 }
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder собирает SQL, который gorm сформировал бы в режиме DryRun
+type sqlRecorder struct {
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r *sqlRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Error(context.Context, string, ...interface{}) {}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// dryRunDB — gorm без подключения к базе: запросы только формируются
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	rec := &sqlRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               rec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, rec
+}
+
+func TestSearchOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		order string
+	}{
+		{"without query", "", "ORDER BY created_at DESC,id DESC LIMIT"},
+		{"ranked by query", "погода", "ORDER BY ts_rank(search_vector, to_tsquery('simple', 'погода:*')) DESC, created_at DESC, id DESC LIMIT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, rec := dryRunDB(t)
+			if _, _, err := NewContentRepository(db).Search(ContentFilter{Query: tt.query, Limit: 20}); err != nil {
+				t.Fatal(err)
+			}
+			if len(rec.statements) != 2 {
+				t.Fatalf("statements = %q", rec.statements)
+			}
+			if count := rec.statements[0]; strings.Contains(count, "ORDER BY") {
+				t.Fatalf("count query is ordered: %s", count)
+			}
+			if find := rec.statements[1]; !strings.Contains(find, tt.order) {
+				t.Fatalf("query %s\nwant %s", find, tt.order)
+			}
+		})
+	}
+}
+
+func TestMigrateReviewStatus(t *testing.T) {
+	db, rec := dryRunDB(t)
+	if err := MigrateReviewStatus(db.Session(&gorm.Session{SkipDefaultTransaction: true})); err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE "contents" SET "review_status"='approved',"updated_at"=`
+	if len(rec.statements) != 1 || !strings.HasPrefix(rec.statements[0], want) ||
+		!strings.HasSuffix(rec.statements[0], `WHERE review_status IS NULL OR review_status = ''`) {
+		t.Fatalf("statements = %q", rec.statements)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/TryHanger/digital_signage/backend/internal/config"
+	"github.com/TryHanger/digital_signage/backend/internal/model"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -33,3 +34,27 @@ func InitDB(cfg *config.Config) *gorm.DB {
 	log.Fatal("Error connect to DB after retries:", err)
 	return nil
 }
+
+// MigrateSearch добавляет то, что AutoMigrate не умеет: генерируемую колонку tsvector
+// для полнотекстового поиска по контенту и GIN-индексы по ней и по тегам
+func MigrateSearch(db *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE contents ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(description, ''))) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_contents_search_vector ON contents USING GIN (search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_contents_tags ON contents USING GIN (tags)`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateReviewStatus одобряет контент, созданный до появления согласования:
+// без статуса он выпал бы из показа, а правка через Update сохранила бы пустой статус
+func MigrateReviewStatus(db *gorm.DB) error {
+	return db.Model(&model.Content{}).Where("review_status IS NULL OR review_status = ''").
+		Update("review_status", model.ReviewApproved).Error
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type DefaultPlaylistRepository struct {
+	db *gorm.DB
+}
+
+func NewDefaultPlaylistRepository(db *gorm.DB) *DefaultPlaylistRepository {
+	return &DefaultPlaylistRepository{db: db}
+}
+
+func (r *DefaultPlaylistRepository) Create(playlist *model.DefaultPlaylist) error {
+	return r.db.Create(playlist).Error
+}
+
+func (r *DefaultPlaylistRepository) GetAll() ([]model.DefaultPlaylist, error) {
+	var playlists []model.DefaultPlaylist
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Find(&playlists).Error
+	return playlists, err
+}
+
+func (r *DefaultPlaylistRepository) GetByID(id uint) (*model.DefaultPlaylist, error) {
+	var playlist model.DefaultPlaylist
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		First(&playlist, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// Update перезаписывает поля плейлиста и полностью заменяет его элементы.
+// В отличие от Save не создаёт строку: для неизвестного ID возвращает gorm.ErrRecordNotFound.
+func (r *DefaultPlaylistRepository) Update(playlist *model.DefaultPlaylist) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(playlist).Select("*").Omit("Items", "CreatedAt").Updates(playlist)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("playlist_id = ?", playlist.ID).Delete(&model.DefaultPlaylistItem{}).Error; err != nil {
+			return err
+		}
+		for i := range playlist.Items {
+			playlist.Items[i].ID = 0
+			playlist.Items[i].PlaylistID = playlist.ID
+		}
+		if len(playlist.Items) == 0 {
+			return nil
+		}
+		return tx.Create(&playlist.Items).Error
+	})
+}
+
+func (r *DefaultPlaylistRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("playlist_id = ?", id).Delete(&model.DefaultPlaylistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.DefaultPlaylist{}, id).Error
+	})
+}
+
+// ExistsForTarget проверяет, есть ли уже плейлист для того же уровня и цели (кроме excludeID)
+func (r *DefaultPlaylistRepository) ExistsForTarget(playlist *model.DefaultPlaylist, excludeID uint) (bool, error) {
+	query := r.db.Model(&model.DefaultPlaylist{}).Where("level = ? AND id <> ?", playlist.Level, excludeID)
+	switch playlist.Level {
+	case model.DefaultLevelLocation:
+		query = query.Where("location_id = ?", playlist.LocationID)
+	case model.DefaultLevelGroup:
+		query = query.Where("group_id = ?", playlist.GroupID)
+	case model.DefaultLevelMonitor:
+		query = query.Where("monitor_id = ?", playlist.MonitorID)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// GetCandidatesForMonitor возвращает все плейлисты по умолчанию, применимые к монитору
+func (r *DefaultPlaylistRepository) GetCandidatesForMonitor(monitor *model.Monitor) ([]model.DefaultPlaylist, error) {
+	var playlists []model.DefaultPlaylist
+	cond := r.db.Where("level = ?", model.DefaultLevelGlobal).
+		Or("level = ? AND location_id = ?", model.DefaultLevelLocation, monitor.LocationID).
+		Or("level = ? AND monitor_id = ?", model.DefaultLevelMonitor, monitor.ID)
+	if monitor.GroupID != nil {
+		cond = cond.Or("level = ? AND group_id = ?", model.DefaultLevelGroup, *monitor.GroupID)
+	}
+	err := r.db.Preload("Items.Content").Where(cond).Find(&playlists).Error
+	return playlists, err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type FeedRepository struct {
+	db *gorm.DB
+}
+
+func NewFeedRepository(db *gorm.DB) *FeedRepository {
+	return &FeedRepository{db: db}
+}
+
+// ReplaceItems заменяет закэшированные записи ленты контента
+func (r *FeedRepository) ReplaceItems(contentID uint, items []model.FeedItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("content_id = ?", contentID).Delete(&model.FeedItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+func (r *FeedRepository) GetItems(contentID uint) ([]model.FeedItem, error) {
+	var items []model.FeedItem
+	err := r.db.Where("content_id = ?", contentID).Order("position").Find(&items).Error
+	return items, err
+}
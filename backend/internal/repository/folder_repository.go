@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type FolderRepository struct {
+	db *gorm.DB
+}
+
+func NewFolderRepository(db *gorm.DB) *FolderRepository {
+	return &FolderRepository{db: db}
+}
+
+func (r *FolderRepository) Create(folder *model.Folder) error {
+	return r.db.Create(folder).Error
+}
+
+func (r *FolderRepository) GetAll() ([]model.Folder, error) {
+	var folders []model.Folder
+	err := r.db.Order("name").Find(&folders).Error
+	return folders, err
+}
+
+func (r *FolderRepository) GetByID(id uint) (*model.Folder, error) {
+	var folder model.Folder
+	err := r.db.First(&folder, id).Error
+	return &folder, err
+}
+
+func (r *FolderRepository) Update(folder *model.Folder) error {
+	return r.db.Model(folder).Select("name", "parent_id").Updates(folder).Error
+}
+
+// ExistsName проверяет, есть ли в родительской папке другая папка с таким именем
+func (r *FolderRepository) ExistsName(parentID *uint, name string, exceptID uint) (bool, error) {
+	var count int64
+	q := r.db.Model(&model.Folder{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, exceptID)
+	if parentID == nil {
+		q = q.Where("parent_id IS NULL")
+	} else {
+		q = q.Where("parent_id = ?", *parentID)
+	}
+	err := q.Count(&count).Error
+	return count > 0, err
+}
+
+// Delete удаляет папку; её подпапки и контент переходят в родительскую папку
+func (r *FolderRepository) Delete(folder *model.Folder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Folder{}).Where("parent_id = ?", folder.ID).Update("parent_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Content{}).Where("folder_id = ?", folder.ID).Update("folder_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Folder{}, folder.ID).Error
+	})
+}
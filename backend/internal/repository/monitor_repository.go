@@ -34,6 +34,12 @@ func (r *MonitorRepository) GetByID(id uint) (*model.Monitor, error) {
 	return &monitor, nil
 }
 
+// UpdateResolution сохраняет заявленное разрешение экрана
+func (r *MonitorRepository) UpdateResolution(id uint, width, height int) error {
+	return r.db.Model(&model.Monitor{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"width": width, "height": height}).Error
+}
+
 func (r *MonitorRepository) GetByToken(token string) (*model.Monitor, error) {
 	var monitor model.Monitor
 	if err := r.db.Preload("Location").First(&monitor, "token = ?", token).Error; err != nil {
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type PlaylistRepository struct {
+	db *gorm.DB
+}
+
+func NewPlaylistRepository(db *gorm.DB) *PlaylistRepository {
+	return &PlaylistRepository{db: db}
+}
+
+// PlaylistUsage — блок шаблона или расписания либо область шаблона, ссылающиеся на плейлист.
+// У области нет блока: заполнено Zone, а ссылка всегда живая.
+type PlaylistUsage struct {
+	Kind      string             `json:"kind"` // template | schedule
+	ID        uint               `json:"id"`
+	Name      string             `json:"name"`
+	BlockID   uint               `json:"blockId,omitempty"`
+	BlockName string             `json:"blockName,omitempty"`
+	Zone      string             `json:"zone,omitempty"`
+	Mode      model.PlaylistMode `json:"mode"`
+}
+
+func (r *PlaylistRepository) Create(playlist *model.Playlist) error {
+	return r.db.Create(playlist).Error
+}
+
+func (r *PlaylistRepository) GetAll() ([]model.Playlist, error) {
+	var playlists []model.Playlist
+	err := r.db.Preload("Items", orderByPosition).Preload("Items.Content").
+		Order("name").Find(&playlists).Error
+	return playlists, err
+}
+
+func (r *PlaylistRepository) GetByID(id uint) (*model.Playlist, error) {
+	var playlist model.Playlist
+	err := r.db.Preload("Items", orderByPosition).Preload("Items.Content").
+		First(&playlist, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// GetByIDs возвращает плейлисты с элементами и контентом, проиндексированные по ID
+func (r *PlaylistRepository) GetByIDs(ids []uint) (map[uint]model.Playlist, error) {
+	result := make(map[uint]model.Playlist, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var playlists []model.Playlist
+	err := r.db.Preload("Items", orderByPosition).Preload("Items.Content").
+		Where("id IN ?", ids).Find(&playlists).Error
+	for _, p := range playlists {
+		result[p.ID] = p
+	}
+	return result, err
+}
+
+// Update перезаписывает поля плейлиста и полностью заменяет его элементы
+func (r *PlaylistRepository) Update(playlist *model.Playlist) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Save(playlist).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("playlist_id = ?", playlist.ID).Delete(&model.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		for i := range playlist.Items {
+			playlist.Items[i].ID = 0
+			playlist.Items[i].PlaylistID = playlist.ID
+		}
+		if len(playlist.Items) == 0 {
+			return nil
+		}
+		return tx.Create(&playlist.Items).Error
+	})
+}
+
+// Delete удаляет плейлист; блоки со снимком сохраняют свои элементы и теряют только ссылку
+func (r *PlaylistRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []interface{}{&model.TemplateBlock{}, &model.ScheduleBlock{}} {
+			if err := tx.Model(m).Where("playlist_id = ?", id).
+				Updates(map[string]interface{}{"playlist_id": nil, "playlist_mode": ""}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.TemplateZone{}).Where("playlist_id = ?", id).Update("playlist_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("playlist_id = ?", id).Delete(&model.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Playlist{}, id).Error
+	})
+}
+
+// Usages возвращает блоки шаблонов и расписаний, ссылающиеся на плейлист
+func (r *PlaylistRepository) Usages(id uint) ([]PlaylistUsage, error) {
+	usages := []PlaylistUsage{}
+	err := r.db.Raw(`
+		SELECT 'template' AS kind, t.id, t.name, b.id AS block_id, b.name AS block_name, '' AS zone, b.playlist_mode AS mode
+		FROM template_blocks b
+		JOIN templates t ON t.id = b.template_id
+		WHERE b.playlist_id = ?
+		UNION ALL
+		SELECT 'template', t.id, t.name, 0, '', z.name, 'link'
+		FROM template_zones z
+		JOIN templates t ON t.id = z.template_id
+		WHERE z.playlist_id = ?
+		UNION ALL
+		SELECT 'schedule', s.id, s.name, b.id, b.name, '', b.playlist_mode
+		FROM schedule_blocks b
+		JOIN schedules s ON s.id = b.schedule_id
+		WHERE b.playlist_id = ?
+		ORDER BY kind, id, block_id, zone`, id, id, id).Scan(&usages).Error
+	return usages, err
+}
+
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+// ZonePlaylistItems — элементы собственного плейлиста области zone: все они играют в ней,
+// какие бы области ни были указаны у самих элементов
+func ZonePlaylistItems(playlist *model.Playlist, zone string) []model.ScheduleBlockItem {
+	items := PlaylistBlockItems(playlist, 0)
+	for i := range items {
+		items[i].Zone = zone
+	}
+	return items
+}
+
+// PlaylistBlockItems — элементы плейлиста в виде элементов блока расписания
+func PlaylistBlockItems(playlist *model.Playlist, blockID uint) []model.ScheduleBlockItem {
+	items := make([]model.ScheduleBlockItem, 0, len(playlist.Items))
+	for _, it := range sortedPlaylistItems(playlist) {
+		items = append(items, model.ScheduleBlockItem{
+			BlockID:      blockID,
+			ContentID:    it.ContentID,
+			Content:      it.Content,
+			Position:     it.Position,
+			Duration:     it.Duration,
+			Zone:         it.Zone,
+			Weight:       it.Weight,
+			PlaysPerHour: it.PlaysPerHour,
+			ValidFrom:    it.ValidFrom,
+			ValidUntil:   it.ValidUntil,
+			Weekdays:     it.Weekdays,
+			StartTime:    it.StartTime,
+			EndTime:      it.EndTime,
+		})
+	}
+	return items
+}
+
+// PlaylistTemplateContents — элементы плейлиста в виде контента блока шаблона.
+// У контента шаблона нет ротации и окон действия: их переносят только блоки расписаний.
+func PlaylistTemplateContents(playlist *model.Playlist, blockID uint) []model.TemplateContent {
+	contents := make([]model.TemplateContent, 0, len(playlist.Items))
+	for i, it := range sortedPlaylistItems(playlist) {
+		c := model.TemplateContent{BlockID: blockID, ContentID: it.ContentID, Order: i + 1, Zone: it.Zone}
+		if it.Content != nil {
+			c.Type = it.Content.Type
+		}
+		if it.Duration != nil {
+			c.Duration = *it.Duration
+		}
+		contents = append(contents, c)
+	}
+	return contents
+}
+
+func sortedPlaylistItems(playlist *model.Playlist) []model.PlaylistItem {
+	items := append([]model.PlaylistItem(nil), playlist.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+	return items
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/lib/pq"
+)
+
+func TestPlaylistBlockItemsCarryItemSettings(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	duration := 15
+	playlist := &model.Playlist{ID: 3, Items: []model.PlaylistItem{
+		{ContentID: 2, Position: 2},
+		{ContentID: 1, Position: 1, Duration: &duration, Zone: "promo", Weight: 3, PlaysPerHour: 4,
+			ValidFrom: &from, Weekdays: pq.Int64Array{1, 5}, StartTime: "10:00", EndTime: "12:00"},
+	}}
+
+	items := PlaylistBlockItems(playlist, 9)
+	want := model.ScheduleBlockItem{BlockID: 9, ContentID: 1, Position: 1, Duration: &duration, Zone: "promo",
+		Weight: 3, PlaysPerHour: 4, ValidFrom: &from, Weekdays: pq.Int64Array{1, 5}, StartTime: "10:00", EndTime: "12:00"}
+	if len(items) != 2 || !reflect.DeepEqual(items[0], want) {
+		t.Fatalf("items[0] = %+v, want %+v", items[0], want)
+	}
+	if items[1].ContentID != 2 {
+		t.Fatalf("items[1].ContentID = %d, want 2", items[1].ContentID)
+	}
+
+	contents := PlaylistTemplateContents(playlist, 9)
+	if len(contents) != 2 || contents[0].Zone != "promo" || contents[0].Duration != 15 || contents[0].Order != 1 {
+		t.Fatalf("contents = %+v", contents)
+	}
+}
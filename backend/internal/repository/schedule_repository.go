@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	"gorm.io/gorm"
 	"time"
@@ -20,18 +22,19 @@ func (r *ScheduleRepository) Create(schedule *model.Schedule) error {
 
 func (r *ScheduleRepository) GetAll() ([]model.Schedule, error) {
 	var schedules []model.Schedule
-	err := r.db.Preload("Blocks.Items.Content").
+	err := preloadBlocks(r.db).
 		Preload("Monitors").
 		Preload("Location").
 		Preload("Group").
 		Preload("Exceptions").
 		Find(&schedules).Error
+	linkPlaylists(schedules)
 	return schedules, err
 }
 
 func (r *ScheduleRepository) GetByID(id uint) (*model.Schedule, error) {
 	var schedule model.Schedule
-	err := r.db.Preload("Blocks.Items.Content").
+	err := preloadBlocks(r.db).
 		Preload("Monitors").
 		Preload("Location").
 		Preload("Group").
@@ -40,12 +43,16 @@ func (r *ScheduleRepository) GetByID(id uint) (*model.Schedule, error) {
 	if err != nil {
 		return nil, err
 	}
-
+	linkBlockPlaylists(schedule.Blocks)
 	return &schedule, nil
 }
 
 func (r *ScheduleRepository) Update(schedule *model.Schedule) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
+		// без явной версии шаблона расписание остаётся на прежней
+		if schedule.TemplateVersion == 0 {
+			tx = tx.Omit("TemplateVersion")
+		}
 		if err := tx.Save(schedule).Error; err != nil {
 			return err
 		}
@@ -59,9 +66,131 @@ func (r *ScheduleRepository) Delete(id uint) error {
 
 func (r *ScheduleRepository) GetActiveOn(date time.Time) ([]model.Schedule, error) {
 	var schedules []model.Schedule
-	err := r.db.Preload("Blocks.Items.Content").
+	err := preloadBlocks(r.db).
 		Where("start_date <= ?", date).
 		Where("end_date IS NULL OR end_date >= ?", date).
 		Find(&schedules).Error
+	linkPlaylists(schedules)
+	return schedules, err
+}
+
+// GetActiveBetween возвращает включённые расписания, период которых пересекается с [from, to]
+func (r *ScheduleRepository) GetActiveBetween(from, to time.Time) ([]model.Schedule, error) {
+	var schedules []model.Schedule
+	err := preloadBlocks(r.db).
+		Where("is_active = ?", true).
+		Where("start_date <= ?", to).
+		Where("end_date IS NULL OR end_date >= ?", from).
+		Order("id").
+		Find(&schedules).Error
+	linkPlaylists(schedules)
 	return schedules, err
 }
+
+// GetForMonitor возвращает активные расписания, назначенные монитору
+// напрямую, через его группу или через его локацию
+func (r *ScheduleRepository) GetForMonitor(monitor *model.Monitor) ([]model.Schedule, error) {
+	var schedules []model.Schedule
+	query := preloadBlocks(r.db).
+		Preload("Template.Zones").
+		Preload("Monitors").
+		Preload("Exceptions").
+		Where("is_active = ?", true)
+
+	cond := r.db.Where("location_id = ?", monitor.LocationID).
+		Or("id IN (?)", r.db.Table("schedule_monitors").Select("schedule_id").Where("monitor_id = ?", monitor.ID))
+	if monitor.GroupID != nil {
+		cond = cond.Or("group_id = ?", *monitor.GroupID)
+	}
+
+	if err := query.Where(cond).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	linkPlaylists(schedules)
+	if err := r.pinTemplateLayouts(schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// pinTemplateLayouts подставляет в шаблоны расписаний холст и области из версии шаблона,
+// за которой закреплено расписание: более поздние правки раскладки его не затрагивают.
+// Расписания без версии показываются с текущей раскладкой.
+func (r *ScheduleRepository) pinTemplateLayouts(schedules []model.Schedule) error {
+	var pairs [][]interface{}
+	for _, s := range schedules {
+		if s.Template != nil && s.TemplateVersion > 0 {
+			pairs = append(pairs, []interface{}{s.TemplateID, s.TemplateVersion})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	var versions []model.TemplateVersion
+	if err := r.db.Where("(template_id, version) IN ?", pairs).Find(&versions).Error; err != nil {
+		return err
+	}
+	return applyTemplateLayouts(schedules, versions)
+}
+
+// applyTemplateLayouts заменяет раскладку шаблонов расписаний раскладкой их версий
+func applyTemplateLayouts(schedules []model.Schedule, versions []model.TemplateVersion) error {
+	layouts := make(map[string]*model.Template, len(versions))
+	for _, v := range versions {
+		var snapshot model.Template
+		if err := json.Unmarshal(v.Snapshot, &snapshot); err != nil {
+			return fmt.Errorf("template %d version %d: %w", v.TemplateID, v.Version, err)
+		}
+		layouts[fmt.Sprintf("%d/%d", v.TemplateID, v.Version)] = &snapshot
+	}
+	for i := range schedules {
+		s := &schedules[i]
+		if s.Template == nil {
+			continue
+		}
+		layout, ok := layouts[fmt.Sprintf("%d/%d", s.TemplateID, s.TemplateVersion)]
+		if !ok {
+			continue
+		}
+		// копия: один шаблон может быть общим для нескольких расписаний разных версий
+		template := *s.Template
+		template.CanvasWidth, template.CanvasHeight, template.Zones = layout.CanvasWidth, layout.CanvasHeight, layout.Zones
+		s.Template = &template
+	}
+	return nil
+}
+
+// GetTemplateZones возвращает области раскладки шаблона
+func (r *ScheduleRepository) GetTemplateZones(templateID uint) ([]model.TemplateZone, error) {
+	var zones []model.TemplateZone
+	err := r.db.Where("template_id = ?", templateID).Find(&zones).Error
+	return zones, err
+}
+
+// LatestTemplateVersion возвращает номер последней версии шаблона
+func (r *ScheduleRepository) LatestTemplateVersion(templateID uint) (int, error) {
+	var template model.Template
+	err := r.db.Select("id", "version").First(&template, templateID).Error
+	return template.Version, err
+}
+
+// preloadBlocks загружает блоки с элементами и плейлистами, на которые они ссылаются
+func preloadBlocks(db *gorm.DB) *gorm.DB {
+	return db.Preload("Blocks.Items.Content").
+		Preload("Blocks.Playlist.Items.Content")
+}
+
+func linkPlaylists(schedules []model.Schedule) {
+	for i := range schedules {
+		linkBlockPlaylists(schedules[i].Blocks)
+	}
+}
+
+// linkBlockPlaylists подставляет в блоки со ссылкой на плейлист (link) его текущие элементы
+func linkBlockPlaylists(blocks []model.ScheduleBlock) {
+	for i := range blocks {
+		if blocks[i].PlaylistMode == model.PlaylistLink && blocks[i].Playlist != nil {
+			blocks[i].Items = PlaylistBlockItems(blocks[i].Playlist, blocks[i].ID)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+func TestPinTemplateLayoutsQuery(t *testing.T) {
+	db, rec := dryRunDB(t)
+	r := NewScheduleRepository(db)
+	template := &model.Template{ID: 3}
+	schedules := []model.Schedule{
+		{ID: 1, TemplateID: 3, TemplateVersion: 2, Template: template},
+		{ID: 2, TemplateID: 3, TemplateVersion: 0, Template: template},
+	}
+	if err := r.pinTemplateLayouts(schedules); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.statements) != 1 || !strings.Contains(rec.statements[0], "(template_id, version) IN ((3,2))") {
+		t.Fatalf("statements = %q", rec.statements)
+	}
+}
+
+func TestApplyTemplateLayouts(t *testing.T) {
+	pinned, err := json.Marshal(model.Template{
+		ID: 3, CanvasWidth: 1920, CanvasHeight: 1080,
+		Zones: []model.TemplateZone{{Name: "promo", Width: 480, Height: 1080}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// текущая версия шаблона — другая раскладка
+	current := &model.Template{ID: 3, Version: 3, CanvasWidth: 1080, CanvasHeight: 1920,
+		Zones: []model.TemplateZone{{Name: "ticker", Width: 1080, Height: 100}}}
+	schedules := []model.Schedule{
+		{ID: 1, TemplateID: 3, TemplateVersion: 2, Template: current},
+		{ID: 2, TemplateID: 3, TemplateVersion: 3, Template: current},
+	}
+	versions := []model.TemplateVersion{{TemplateID: 3, Version: 2, Snapshot: pinned}}
+	if err := applyTemplateLayouts(schedules, versions); err != nil {
+		t.Fatal(err)
+	}
+
+	got := schedules[0].Template
+	if got.CanvasWidth != 1920 || len(got.Zones) != 1 || got.Zones[0].Name != "promo" {
+		t.Fatalf("pinned layout = %+v", got)
+	}
+	if schedules[1].Template != current || current.Zones[0].Name != "ticker" {
+		t.Fatalf("current layout changed: %+v", schedules[1].Template)
+	}
+}
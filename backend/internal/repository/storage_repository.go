@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StorageRepository считает место, занятое файлами контента. Файл хранится один раз
+// на SHA-256, поэтому учитываются различные файлы всех версий, а не записи контента.
+type StorageRepository struct {
+	db *gorm.DB
+}
+
+func NewStorageRepository(db *gorm.DB) *StorageRepository {
+	return &StorageRepository{db: db}
+}
+
+// OwnerUsage — место и количество контента одной локации; LocationID nil — общий контент
+type OwnerUsage struct {
+	LocationID *uint `json:"locationId"`
+	UsedBytes  int64 `json:"usedBytes"`
+	Files      int64 `json:"files"`
+	Contents   int64 `json:"contents"`
+}
+
+// locationFiles — различные файлы версий контента, сгруппированные по локации-владельцу
+const locationFiles = `SELECT DISTINCT c.location_id, v.checksum, v.size
+	FROM content_versions v JOIN contents c ON c.id = v.content_id`
+
+// UsageByLocation возвращает занятое место по всем локациям
+func (r *StorageRepository) UsageByLocation() ([]OwnerUsage, error) {
+	var usage []OwnerUsage
+	err := r.db.Raw(`
+		WITH files AS (` + locationFiles + `),
+		used AS (SELECT location_id, SUM(size) AS used_bytes, COUNT(*) AS files FROM files GROUP BY location_id),
+		counts AS (SELECT location_id, COUNT(*) AS contents FROM contents GROUP BY location_id)
+		SELECT counts.location_id, COALESCE(used.used_bytes, 0) AS used_bytes,
+			COALESCE(used.files, 0) AS files, counts.contents
+		FROM counts LEFT JOIN used ON used.location_id IS NOT DISTINCT FROM counts.location_id
+		ORDER BY counts.location_id NULLS FIRST`).Scan(&usage).Error
+	return usage, err
+}
+
+// LockLocation читает локацию и блокирует её строку до конца транзакции (SELECT ... FOR UPDATE):
+// проверки квоты одной локации в параллельных транзакциях выполняются по очереди
+func (r *StorageRepository) LockLocation(id uint) (*model.Location, error) {
+	var location model.Location
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&location, id).Error
+	return &location, err
+}
+
+// LocationUsage — место, занятое файлами контента локации
+func (r *StorageRepository) LocationUsage(locationID uint) (int64, error) {
+	var used int64
+	err := r.db.Raw(`SELECT COALESCE(SUM(size), 0) FROM (`+locationFiles+` WHERE c.location_id = ?) files`, locationID).
+		Scan(&used).Error
+	return used, err
+}
+
+// LocationHasFile — файл уже учтён в квоте локации
+func (r *StorageRepository) LocationHasFile(locationID uint, checksum string) (bool, error) {
+	var count int64
+	err := r.db.Table("content_versions v").
+		Joins("JOIN contents c ON c.id = v.content_id").
+		Where("c.location_id = ? AND v.checksum = ?", locationID, checksum).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// TotalUsage — место, занятое всеми файлами в хранилище
+func (r *StorageRepository) TotalUsage() (int64, error) {
+	var used int64
+	err := r.db.Raw(`SELECT COALESCE(SUM(size), 0) FROM (SELECT DISTINCT checksum, size FROM content_versions) files`).
+		Scan(&used).Error
+	return used, err
+}
+
+// Largest возвращает контент с самыми большими файлами; locationID nil — по всем локациям
+func (r *StorageRepository) Largest(locationID *uint, limit int) ([]model.Content, error) {
+	var contents []model.Content
+	err := r.filesOf(locationID).Order("size DESC").Order("id").Limit(limit).Find(&contents).Error
+	return contents, err
+}
+
+// Unused возвращает контент с файлами, на который не ссылаются шаблоны,
+// расписания и плейлисты, от больших файлов к меньшим
+func (r *StorageRepository) Unused(locationID *uint, limit int) ([]model.Content, error) {
+	var contents []model.Content
+	err := r.filesOf(locationID).
+		Where("NOT EXISTS (SELECT 1 FROM template_contents t WHERE t.content_id = contents.id)").
+		Where("NOT EXISTS (SELECT 1 FROM schedule_block_items i WHERE i.content_id = contents.id)").
+		Where("NOT EXISTS (SELECT 1 FROM default_playlist_items d WHERE d.content_id = contents.id)").
+		Where("NOT EXISTS (SELECT 1 FROM playlist_items p WHERE p.content_id = contents.id)").
+		Order("size DESC").Order("id").Limit(limit).Find(&contents).Error
+	return contents, err
+}
+
+func (r *StorageRepository) filesOf(locationID *uint) *gorm.DB {
+	q := r.db.Model(&model.Content{}).Where("checksum <> ''")
+	if locationID != nil {
+		q = q.Where("location_id = ?", *locationID)
+	}
+	return q
+}
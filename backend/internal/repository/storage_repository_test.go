@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLockLocationLocksRow(t *testing.T) {
+	db, rec := dryRunDB(t)
+	if _, err := NewContentRepository(db).Storage().LockLocation(7); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.statements) != 1 || !strings.HasSuffix(rec.statements[0], "FOR UPDATE") {
+		t.Fatalf("statements = %q, want a SELECT ... FOR UPDATE", rec.statements)
+	}
+}
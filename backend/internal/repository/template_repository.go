@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	"gorm.io/gorm"
 )
@@ -9,45 +13,117 @@ type TemplateRepository struct {
 	db *gorm.DB
 }
 
+// OutdatedSchedule is a schedule created from an older version of its template
+type OutdatedSchedule struct {
+	ID              uint   `json:"id"`
+	Name            string `json:"name"`
+	TemplateVersion int    `json:"templateVersion"`
+	LatestVersion   int    `json:"latestVersion"`
+}
+
 func NewTemplateRepository(db *gorm.DB) *TemplateRepository {
 	return &TemplateRepository{db: db}
 }
 
 func (r *TemplateRepository) CreateTemplate(template *model.Template) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
+		template.Version = 1
 		if err := tx.Create(template).Error; err != nil {
 			return err
 		}
-		return nil
+		return saveTemplateVersion(tx, template, nil)
 	})
 }
 
 func (r *TemplateRepository) GetAll() ([]model.Template, error) {
 	var templates []model.Template
-	err := r.db.Preload("Blocks").Preload("Blocks.Contents").Find(&templates).Error
+	err := preloadTemplateBlocks(r.db).Find(&templates).Error
+	for i := range templates {
+		linkTemplatePlaylists(templates[i].Blocks)
+	}
 	return templates, err
 }
 
 func (r *TemplateRepository) GetByID(id uint) (*model.Template, error) {
 	var template model.Template
-	err := r.db.Preload("Blocks").Preload("Blocks.Contents").First(&template, id).Error
+	err := preloadTemplateBlocks(r.db).First(&template, id).Error
+	linkTemplatePlaylists(template.Blocks)
 	return &template, err
 }
 
 func (r *TemplateRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Template{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", id).Delete(&model.TemplateVersion{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Template{}, id).Error
+	})
 }
 
-func (r *TemplateRepository) UpdateTemplate(template *model.Template) error {
+// NameExists reports whether a template with this name already exists
+func (r *TemplateRepository) NameExists(name string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Template{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
+
+// Versions returns the template's versions without snapshots, newest first
+func (r *TemplateRepository) Versions(templateID uint) ([]model.TemplateVersion, error) {
+	versions := []model.TemplateVersion{}
+	err := r.db.Omit("Snapshot").Where("template_id = ?", templateID).
+		Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+func (r *TemplateRepository) GetVersion(templateID uint, version int) (*model.TemplateVersion, error) {
+	var v model.TemplateVersion
+	err := r.db.Where("template_id = ? AND version = ?", templateID, version).First(&v).Error
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// OutdatedSchedules returns schedules pinned to a version older than the template's latest
+func (r *TemplateRepository) OutdatedSchedules(templateID uint) ([]OutdatedSchedule, error) {
+	schedules := []OutdatedSchedule{}
+	err := r.db.Raw(`
+		SELECT s.id, s.name, s.template_version, t.version AS latest_version
+		FROM schedules s
+		JOIN templates t ON t.id = s.template_id
+		WHERE t.id = ? AND s.template_version < t.version
+		ORDER BY s.template_version, s.id`, templateID).Scan(&schedules).Error
+	return schedules, err
+}
+
+// UpdateTemplate saves the template and creates a new version when changed reports
+// a difference between the latest version's snapshot and the template as saved
+func (r *TemplateRepository) UpdateTemplate(template *model.Template, changed func(latest, saved *model.Template) bool) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		// update top-level template fields
 		if err := tx.Model(&model.Template{}).Where("id = ?", template.ID).Updates(map[string]interface{}{
-			"name":        template.Name,
-			"description": template.Description,
+			"name":          template.Name,
+			"description":   template.Description,
+			"canvas_width":  template.CanvasWidth,
+			"canvas_height": template.CanvasHeight,
 		}).Error; err != nil {
 			return err
 		}
 
+		// zones are replaced as a whole: content refers to them by name, not by id
+		if err := tx.Where("template_id = ?", template.ID).Delete(&model.TemplateZone{}).Error; err != nil {
+			return err
+		}
+		for i := range template.Zones {
+			template.Zones[i].ID = 0
+			template.Zones[i].TemplateID = template.ID
+		}
+		if len(template.Zones) > 0 {
+			if err := tx.Create(&template.Zones).Error; err != nil {
+				return err
+			}
+		}
+
 		// load existing blocks (with contents) for diffing
 		var existingBlocks []model.TemplateBlock
 		if err := tx.Where("template_id = ?", template.ID).Preload("Contents").Find(&existingBlocks).Error; err != nil {
@@ -69,9 +145,11 @@ func (r *TemplateRepository) UpdateTemplate(template *model.Template) error {
 				// if block exists - update fields
 				if _, ok := existingBlocksMap[b.ID]; ok {
 					if err := tx.Model(&model.TemplateBlock{}).Where("id = ?", b.ID).Updates(map[string]interface{}{
-						"name":       b.Name,
-						"start_time": b.StartTime,
-						"end_time":   b.EndTime,
+						"name":          b.Name,
+						"start_time":    b.StartTime,
+						"end_time":      b.EndTime,
+						"playlist_id":   b.PlaylistID,
+						"playlist_mode": b.PlaylistMode,
 					}).Error; err != nil {
 						return err
 					}
@@ -99,6 +177,7 @@ func (r *TemplateRepository) UpdateTemplate(template *model.Template) error {
 									"duration":   c.Duration,
 									"order":      c.Order,
 									"type":       c.Type,
+									"zone":       c.Zone,
 								}).Error; err != nil {
 									return err
 								}
@@ -164,6 +243,66 @@ func (r *TemplateRepository) UpdateTemplate(template *model.Template) error {
 			}
 		}
 
-		return nil
+		return saveTemplateVersion(tx, template, changed)
 	})
 }
+
+// saveTemplateVersion stores an immutable snapshot of the template as just saved.
+// Linked playlist blocks are snapshotted with the playlist items they show right now.
+// With changed, the version number only grows when the snapshot differs from the latest one;
+// without it (a new template) the first version is stored as is.
+func saveTemplateVersion(tx *gorm.DB, template *model.Template, changed func(latest, saved *model.Template) bool) error {
+	var saved model.Template
+	if err := preloadTemplateBlocks(tx).First(&saved, template.ID).Error; err != nil {
+		return err
+	}
+	linkTemplatePlaylists(saved.Blocks)
+
+	if changed != nil {
+		var latest model.TemplateVersion
+		err := tx.Where("template_id = ?", saved.ID).Order("version DESC").First(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			var previous model.Template
+			if err := json.Unmarshal(latest.Snapshot, &previous); err != nil {
+				return fmt.Errorf("template %d version %d: %w", saved.ID, latest.Version, err)
+			}
+			if !changed(&previous, &saved) {
+				template.Version = saved.Version
+				return nil
+			}
+		}
+		// the row is locked by the update above, so the next number is taken by this transaction only
+		saved.Version++
+		if err := tx.Model(&model.Template{}).Where("id = ?", saved.ID).Update("version", saved.Version).Error; err != nil {
+			return err
+		}
+	}
+	snapshot, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	template.Version = saved.Version
+	return tx.Create(&model.TemplateVersion{
+		TemplateID: saved.ID,
+		Version:    saved.Version,
+		Snapshot:   snapshot,
+	}).Error
+}
+
+func preloadTemplateBlocks(db *gorm.DB) *gorm.DB {
+	return db.Preload("Blocks").Preload("Blocks.Contents").
+		Preload("Blocks.Playlist.Items.Content").
+		Preload("Zones", func(db *gorm.DB) *gorm.DB { return db.Order("z_index, id") })
+}
+
+// linkTemplatePlaylists подставляет в блоки со ссылкой на плейлист (link) его текущие элементы
+func linkTemplatePlaylists(blocks []model.TemplateBlock) {
+	for i := range blocks {
+		if blocks[i].PlaylistMode == model.PlaylistLink && blocks[i].Playlist != nil {
+			blocks[i].Contents = PlaylistTemplateContents(blocks[i].Playlist, blocks[i].ID)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+func (r *UploadRepository) Create(upload *model.Upload) error {
+	return r.db.Create(upload).Error
+}
+
+func (r *UploadRepository) GetByID(id string) (*model.Upload, error) {
+	var upload model.Upload
+	if err := r.db.First(&upload, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *UploadRepository) Update(upload *model.Upload) error {
+	return r.db.Save(upload).Error
+}
+
+func (r *UploadRepository) Delete(id string) error {
+	return r.db.Delete(&model.Upload{}, "id = ?", id).Error
+}
+
+// GetExpired возвращает загрузки, срок хранения которых истёк к моменту now
+func (r *UploadRepository) GetExpired(now time.Time) ([]model.Upload, error) {
+	var uploads []model.Upload
+	err := r.db.Where("expires_at < ?", now).Find(&uploads).Error
+	return uploads, err
+}
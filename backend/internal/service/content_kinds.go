@@ -0,0 +1,261 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/media"
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+// contentKind описывает вид контента: какие поля обязательны, как он проверяется
+// и сколько показывается, если длительность не задана
+type contentKind struct {
+	// mimePrefixes — допустимые MIME загруженного файла; пусто — файл не загружается
+	mimePrefixes []string
+	// schemes — допустимые схемы URL в Path; пусто — Path не обязан быть URL
+	schemes []string
+	// validate — дополнительные проверки вида
+	validate func(c *model.Content) error
+	// defaultDuration — длительность показа без явной (0 — длительность обязательна)
+	defaultDuration func(c *model.Content) int
+}
+
+var contentKinds = map[string]contentKind{
+	model.ContentTypeImage: {
+		mimePrefixes:    []string{"image/"},
+		validate:        requirePathOrFile,
+		defaultDuration: fixedDuration(10),
+	},
+	model.ContentTypeVideo: {
+		mimePrefixes: []string{"video/"},
+		validate:     requirePathOrFile,
+		// длительность ролика извлекается из файла; без неё нужно указать вручную
+		defaultDuration: func(c *model.Content) int { return c.MediaDuration },
+	},
+	model.ContentTypeWeb: {
+		schemes:         []string{"http", "https"},
+		validate:        validateWebOptions,
+		defaultDuration: fixedDuration(30),
+	},
+	model.ContentTypeStream: {
+		schemes: []string{"http", "https", "rtsp", "rtsps", "rtmp", "rtmps", "srt", "udp"},
+		// у трансляции нет конца — сколько её показывать, решает пользователь
+		defaultDuration: fixedDuration(0),
+	},
+	model.ContentTypeHTML5: {
+		mimePrefixes:    []string{"application/zip"},
+		validate:        requirePathOrFile,
+		defaultDuration: fixedDuration(30),
+	},
+	model.ContentTypeFeed: {
+		schemes:         []string{"http", "https"},
+		validate:        validateFeedOptions,
+		defaultDuration: fixedDuration(30),
+	},
+	model.ContentTypeText: {
+		validate: func(c *model.Content) error {
+			if strings.TrimSpace(c.Body) == "" {
+				return errors.New("text content requires body")
+			}
+			return nil
+		},
+		defaultDuration: textDuration,
+	},
+}
+
+// contentTypeAliases — прежние названия видов, которые ещё присылают клиенты
+var contentTypeAliases = map[string]string{
+	"url": model.ContentTypeWeb,
+}
+
+// normalizeContentType приводит вид к каноническому имени из contentKinds
+func normalizeContentType(t string) (string, error) {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if alias, ok := contentTypeAliases[t]; ok {
+		t = alias
+	}
+	if _, ok := contentKinds[t]; !ok {
+		return "", fmt.Errorf("unknown content type %q (expected one of: %s)", t, strings.Join(contentTypeNames(), ", "))
+	}
+	return t, nil
+}
+
+// validateContent проверяет контент по правилам его вида; Type нормализуется.
+// allowedHosts — домены, разрешённые для URL веб-страниц и трансляций (пусто — любые).
+func validateContent(c *model.Content, allowedHosts []string) error {
+	t, err := normalizeContentType(c.Type)
+	if err != nil {
+		return err
+	}
+	c.Type = t
+	kind := contentKinds[t]
+
+	if strings.TrimSpace(c.Title) == "" {
+		return errors.New("title is required")
+	}
+	if c.Duration < 0 {
+		return errors.New("duration must not be negative")
+	}
+	if c.ValidFrom != nil && c.ValidUntil != nil && c.ValidUntil.Before(*c.ValidFrom) {
+		return errors.New("validUntil is before validFrom")
+	}
+	if c.Checksum != "" {
+		if len(kind.mimePrefixes) == 0 {
+			return fmt.Errorf("%s content cannot have an uploaded file", t)
+		}
+		if !hasAnyPrefix(c.MimeType, kind.mimePrefixes) {
+			return fmt.Errorf("file of type %s cannot be used as %s content", c.MimeType, t)
+		}
+	}
+	if len(kind.schemes) > 0 {
+		if err := validateURL(c.Path, kind.schemes, allowedHosts); err != nil {
+			return fmt.Errorf("%s content: %w", t, err)
+		}
+	}
+	if t != model.ContentTypeWeb && c.Zoom != 0 {
+		return fmt.Errorf("zoom applies only to %s content", model.ContentTypeWeb)
+	}
+	if t != model.ContentTypeWeb && t != model.ContentTypeFeed && c.RefreshInterval != 0 {
+		return fmt.Errorf("refresh interval applies only to %s and %s content", model.ContentTypeWeb, model.ContentTypeFeed)
+	}
+	if t != model.ContentTypeFeed && (c.FeedMaxItems != 0 || c.FeedFilter != "") {
+		return fmt.Errorf("feed options apply only to %s content", model.ContentTypeFeed)
+	}
+	if c.FitMode != "" || c.FocusX != nil || c.FocusY != nil {
+		if t != model.ContentTypeImage {
+			return fmt.Errorf("fit mode and focus apply only to %s content", model.ContentTypeImage)
+		}
+		if c.FitMode != "" && !media.ValidRenditionMode(c.FitMode) {
+			return fmt.Errorf("unknown fit mode %q", c.FitMode)
+		}
+		for _, f := range []*float64{c.FocusX, c.FocusY} {
+			if f != nil && (*f < 0 || *f > 1) {
+				return errors.New("focus must be between 0 and 1")
+			}
+		}
+	}
+	if kind.validate != nil {
+		if err := kind.validate(c); err != nil {
+			return err
+		}
+	}
+	if contentDuration(c) == 0 {
+		return fmt.Errorf("%s content requires duration", t)
+	}
+	return nil
+}
+
+// contentDuration — длительность показа: заданная вручную, извлечённая из файла
+// или принятая для вида по умолчанию; 0 — определить нельзя
+func contentDuration(c *model.Content) int {
+	if d := c.EffectiveDuration(); d > 0 {
+		return d
+	}
+	if kind, ok := contentKinds[c.Type]; ok {
+		return kind.defaultDuration(c)
+	}
+	// вид неизвестен (старые записи) — как раньше, длительность по умолчанию
+	return defaultItemDuration
+}
+
+// contentTypeForMime определяет вид загруженного файла по MIME
+func contentTypeForMime(mime string) (string, error) {
+	names := contentTypeNames()
+	for _, name := range names {
+		if hasAnyPrefix(mime, contentKinds[name].mimePrefixes) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported file type %s", mime)
+}
+
+func contentTypeNames() []string {
+	names := make([]string, 0, len(contentKinds))
+	for name := range contentKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func requirePathOrFile(c *model.Content) error {
+	if strings.TrimSpace(c.Path) == "" && c.Checksum == "" {
+		return fmt.Errorf("%s content requires a path or an uploaded file", c.Type)
+	}
+	return nil
+}
+
+// validateWebOptions проверяет период перезагрузки и масштаб веб-страницы
+func validateWebOptions(c *model.Content) error {
+	if c.RefreshInterval < 0 {
+		return errors.New("refresh interval must not be negative")
+	}
+	if c.Zoom != 0 && (c.Zoom < 0.25 || c.Zoom > 5) {
+		return errors.New("zoom must be between 0.25 and 5")
+	}
+	return nil
+}
+
+// validateFeedOptions проверяет период обновления и число записей ленты
+func validateFeedOptions(c *model.Content) error {
+	if c.RefreshInterval != 0 && c.RefreshInterval < int(minFeedRefresh/time.Second) {
+		return fmt.Errorf("feed refresh interval must be at least %d seconds", int(minFeedRefresh/time.Second))
+	}
+	if c.FeedMaxItems < 0 || c.FeedMaxItems > maxFeedItems {
+		return fmt.Errorf("feed max items must be between 0 and %d", maxFeedItems)
+	}
+	return nil
+}
+
+func validateURL(raw string, schemes, allowedHosts []string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid URL %q", raw)
+	}
+	if !hostAllowed(u.Hostname(), allowedHosts) {
+		return fmt.Errorf("domain %q is not in the allowlist", u.Hostname())
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return nil
+		}
+	}
+	return fmt.Errorf("URL scheme %q is not allowed (expected one of: %s)", u.Scheme, strings.Join(schemes, ", "))
+}
+
+// hostAllowed — host совпадает с разрешённым доменом или является его поддоменом
+func hostAllowed(host string, allowedHosts []string) bool {
+	if len(allowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, d := range allowedHosts {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func fixedDuration(seconds int) func(*model.Content) int {
+	return func(*model.Content) int { return seconds }
+}
+
+// textDuration — время на чтение: ~3 слова в секунду, но не меньше 5 секунд
+func textDuration(c *model.Content) int {
+	return max(5, len(strings.Fields(c.Body))/3)
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReviewState     = errors.New("invalid review state")
+	ErrReviewForbidden = errors.New("review is not allowed")
+)
+
+// RoleReviewer — роль головного офиса: только она одобряет и отклоняет контент
+const RoleReviewer = "head-office"
+
+// Reviewer — пользователь, выполняющий переход согласования, и его роли
+type Reviewer struct {
+	Name  string
+	Roles []string
+}
+
+func (r Reviewer) HasRole(role string) bool {
+	for _, x := range r.Roles {
+		if x == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Submit отправляет черновик или отклонённый контент на согласование
+func (s *ContentService) Submit(id uint, user Reviewer) (*model.Content, error) {
+	return s.review(id, model.ReviewPending, user, "", model.ReviewDraft, model.ReviewRejected)
+}
+
+// Approve одобряет контент, ожидающий согласования: с этого момента он попадает в эфир
+func (s *ContentService) Approve(id uint, reviewer Reviewer, comment string) (*model.Content, error) {
+	return s.review(id, model.ReviewApproved, reviewer, comment, model.ReviewPending)
+}
+
+// Reject отклоняет контент, ожидающий согласования; причина обязательна
+func (s *ContentService) Reject(id uint, reviewer Reviewer, comment string) (*model.Content, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, fmt.Errorf("%w: rejection comment is required", ErrInvalidContent)
+	}
+	return s.review(id, model.ReviewRejected, reviewer, comment, model.ReviewPending)
+}
+
+func (s *ContentService) review(id uint, to string, user Reviewer, comment string, from ...string) (*model.Content, error) {
+	content, err := s.getByID(id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, f := range from {
+		allowed = allowed || content.ReviewStatus == f
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: content is %s, expected %s", ErrReviewState, content.ReviewStatus, strings.Join(from, " or "))
+	}
+	user.Name = strings.TrimSpace(user.Name)
+	if to == model.ReviewPending {
+		content.SubmittedBy = user.Name
+	} else if err := s.checkReviewer(content, user); err != nil {
+		return nil, err
+	}
+	content.ReviewStatus = to
+	content.ReviewedBy = ""
+	content.ReviewComment = strings.TrimSpace(comment)
+	content.ReviewedAt = nil
+	if to != model.ReviewPending {
+		now := time.Now()
+		content.ReviewedBy = user.Name
+		content.ReviewedAt = &now
+	}
+	if err := s.repo.UpdateReview(content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// checkReviewer разрешает одобрять и отклонять только головному офису и только чужой
+// контент: автор не может согласовать ни отправленный им контент, ни загруженный им файл
+func (s *ContentService) checkReviewer(content *model.Content, reviewer Reviewer) error {
+	if reviewer.Name == "" {
+		return fmt.Errorf("%w: reviewer identity is required", ErrReviewForbidden)
+	}
+	if !reviewer.HasRole(RoleReviewer) {
+		return fmt.Errorf("%w: only %s can review content", ErrReviewForbidden, RoleReviewer)
+	}
+	author := content.SubmittedBy == reviewer.Name
+	if !author && content.Version > 0 {
+		v, err := s.repo.GetVersion(content.ID, content.Version)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		author = err == nil && v.UploadedBy == reviewer.Name
+	}
+	if author {
+		return fmt.Errorf("%w: content cannot be reviewed by its author", ErrReviewForbidden)
+	}
+	return nil
+}
+
+// resetReview возвращает контент в черновики: изменилось то, что видят зрители
+func resetReview(content *model.Content) {
+	content.ReviewStatus = model.ReviewDraft
+	content.SubmittedBy = ""
+	content.ReviewedBy = ""
+	content.ReviewComment = ""
+	content.ReviewedAt = nil
+}
+
+// reviewWarning — предупреждение о неодобренном контенте в блоке; пусто, если контент одобрен
+func reviewWarning(block string, content model.Content) string {
+	if content.ReviewStatus == model.ReviewApproved {
+		return ""
+	}
+	return fmt.Sprintf("block %q: content %d %q is %s and will not be played", block, content.ID, content.Title, content.ReviewStatus)
+}
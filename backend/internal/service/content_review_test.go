@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"gorm.io/gorm/schema"
+)
+
+func TestCheckReviewer(t *testing.T) {
+	headOffice := []string{"franchise", RoleReviewer}
+	tests := []struct {
+		name     string
+		reviewer Reviewer
+		wantErr  bool
+	}{
+		{"head office", Reviewer{Name: "olga", Roles: headOffice}, false},
+		{"anonymous", Reviewer{Roles: headOffice}, true},
+		{"without role", Reviewer{Name: "olga", Roles: []string{"franchise"}}, true},
+		{"submitter approves own content", Reviewer{Name: "ivan", Roles: headOffice}, true},
+	}
+	s := &ContentService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := &model.Content{ID: 1, SubmittedBy: "ivan"}
+			err := s.checkReviewer(content, tt.reviewer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrReviewForbidden) {
+				t.Fatalf("err = %v, want ErrReviewForbidden", err)
+			}
+		})
+	}
+}
+
+func TestLegacyContentStillPlays(t *testing.T) {
+	// строка, созданная до согласования, получает статус из значения колонки по умолчанию
+	sch, err := schema.Parse(&model.Content{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := &model.Content{ID: 1, Type: model.ContentTypeText, Duration: 10, ReviewStatus: sch.LookUpField("review_status").DefaultValue}
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	if items := playableItems([]model.ScheduleBlockItem{{ContentID: 1, Content: legacy}}, at, at); len(items) != 1 {
+		t.Fatalf("legacy content with status %q does not play", legacy.ReviewStatus)
+	}
+}
@@ -1,24 +1,120 @@
 package service
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/media"
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFileTooLarge    = errors.New("file exceeds maximum upload size")
+	ErrInvalidContent  = errors.New("invalid content")
+	ErrContentNotFound = errors.New("content not found")
+	ErrContentInUse    = errors.New("content is in use")
+)
+
+// Режимы удаления контента, на который есть ссылки
+const (
+	DeleteModeCascade = "cascade" // удалить и ссылающиеся элементы
+	DeleteModeReplace = "replace" // заменить ссылки другим контентом
 )
 
+// maxPosterSize — предел размера постера видео
+const maxPosterSize = 20 << 20
+
 type ContentService struct {
-	repo *repository.ContentRepository
+	repo          *repository.ContentRepository
+	store         storage.Storage
+	storage       *StorageService
+	maxUploadSize int64
+	allowedHosts  []string
 }
 
-func NewContentService(repo *repository.ContentRepository) *ContentService {
-	return &ContentService{repo: repo}
+func NewContentService(repo *repository.ContentRepository, store storage.Storage, storageService *StorageService, maxUploadSize int64, allowedHosts []string) *ContentService {
+	return &ContentService{repo: repo, store: store, storage: storageService, maxUploadSize: maxUploadSize, allowedHosts: allowedHosts}
+}
+
+// StoredFile — результат сохранения загруженного файла в хранилище
+type StoredFile struct {
+	Checksum string
+	Size     int64
+	MimeType string
+	Media    *media.Info // nil, если формат не распознан
+	// Thumbnail — SHA-256 источника превью; пусто, если превью построить не удалось
+	Thumbnail string
 }
 
 func (s *ContentService) Create(content *model.Content) error {
+	if err := s.validate(content); err != nil {
+		return err
+	}
+	resetReview(content)
 	return s.repo.Create(content)
 }
 
-func (s *ContentService) GetAll() ([]model.Content, error) {
-	return s.repo.GetAll()
+// Размер страницы библиотеки контента
+const (
+	defaultContentPageSize = 50
+	maxContentPageSize     = 200
+)
+
+// ContentPage — страница результатов поиска по библиотеке
+type ContentPage struct {
+	Data     []model.Content `json:"data"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+}
+
+// Search ищет контент по фильтру; page начинается с 1
+func (s *ContentService) Search(filter repository.ContentFilter, page, pageSize int) (*ContentPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultContentPageSize
+	}
+	pageSize = min(pageSize, maxContentPageSize)
+	for i, t := range filter.Types {
+		normalized, err := normalizeContentType(t)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidContent, err)
+		}
+		filter.Types[i] = normalized
+	}
+	filter.Tags = normalizeTags(filter.Tags)
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+
+	contents, total, err := s.repo.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+	if contents == nil {
+		contents = []model.Content{}
+	}
+	return &ContentPage{Data: contents, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// Tags — все теги библиотеки с количеством контента
+func (s *ContentService) Tags() ([]repository.TagCount, error) {
+	return s.repo.Tags()
 }
 
 func (s *ContentService) GetByID(id uint) (*model.Content, error) {
@@ -26,9 +122,548 @@ func (s *ContentService) GetByID(id uint) (*model.Content, error) {
 }
 
 func (s *ContentService) Update(content *model.Content) error {
-	return s.repo.Update(content)
+	existing, err := s.repo.GetByID(content.ID)
+	if err != nil {
+		return err
+	}
+	// сведения о файле задаются только загрузкой, не из JSON
+	content.Size = existing.Size
+	content.Checksum = existing.Checksum
+	content.MimeType = existing.MimeType
+	content.MediaDuration = existing.MediaDuration
+	content.Width = existing.Width
+	content.Height = existing.Height
+	content.Orientation = existing.Orientation
+	content.Codec = existing.Codec
+	content.Thumbnail = existing.Thumbnail
+	content.Version = existing.Version
+	content.CreatedAt = existing.CreatedAt
+	// результат проверки доступности действителен, пока не сменился адрес
+	content.HealthStatus, content.HealthError, content.HealthCheckedAt = "", "", nil
+	content.FeedFetchedAt = nil
+	if content.Path == existing.Path {
+		content.HealthStatus = existing.HealthStatus
+		content.HealthError = existing.HealthError
+		content.HealthCheckedAt = existing.HealthCheckedAt
+		content.FeedFetchedAt = existing.FeedFetchedAt
+	}
+	if err := s.validate(content); err != nil {
+		return err
+	}
+	// согласование задаётся только через submit/approve/reject и сбрасывается,
+	// если изменилось то, что увидят зрители
+	resetReview(content)
+	if content.Type == existing.Type && content.Path == existing.Path && content.Body == existing.Body {
+		content.ReviewStatus = existing.ReviewStatus
+		content.ReviewedBy = existing.ReviewedBy
+		content.ReviewComment = existing.ReviewComment
+		content.ReviewedAt = existing.ReviewedAt
+	}
+	if content.Checksum == "" || sameLocation(content.LocationID, existing.LocationID) {
+		return s.repo.Update(content)
+	}
+	return s.repo.Transaction(func(tx *repository.ContentRepository) error {
+		if err := s.checkMoveQuota(tx, content); err != nil {
+			return err
+		}
+		return tx.Update(content)
+	})
+}
+
+// Usages возвращает шаблоны, расписания и плейлисты, в которых используется контент
+func (s *ContentService) Usages(id uint) ([]repository.ContentUsage, error) {
+	if _, err := s.getByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.Usages(id)
+}
+
+// Delete удаляет контент. Если на него есть ссылки, без mode возвращается ErrContentInUse;
+// mode=cascade удаляет ссылающиеся элементы, mode=replace переводит их на контент replaceWith.
+// Файл и превью удаляются из хранилища, только когда они не нужны другому контенту.
+func (s *ContentService) Delete(id uint, mode string, replaceWith uint) error {
+	content, err := s.getByID(id)
+	if err != nil {
+		return err
+	}
+	switch mode {
+	case "":
+		var usages []repository.ContentUsage
+		usages, err = s.repo.DeleteUnused(id)
+		if err == nil && len(usages) > 0 {
+			return ErrContentInUse
+		}
+	case DeleteModeCascade:
+		err = s.repo.Delete(id)
+	case DeleteModeReplace:
+		if replaceWith == 0 || replaceWith == id {
+			return fmt.Errorf("%w: replace mode requires another content id in with", ErrInvalidContent)
+		}
+		replacement, getErr := s.repo.GetByID(replaceWith)
+		if errors.Is(getErr, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: replacement content %d not found", ErrInvalidContent, replaceWith)
+		}
+		if getErr != nil {
+			return getErr
+		}
+		err = s.repo.ReplaceAndDelete(id, replacement)
+	default:
+		return fmt.Errorf("%w: unknown delete mode %q", ErrInvalidContent, mode)
+	}
+	if err != nil {
+		return err
+	}
+	s.releaseObjects(content.Checksum, content.Thumbnail)
+	return nil
+}
+
+// releaseObjects удаляет из хранилища файл и превью, на которые больше не ссылается ни один контент.
+// Ошибки только логируются: запись уже удалена, а лишний файл ничему не мешает.
+func (s *ContentService) releaseObjects(checksum, thumbnail string) {
+	if checksum != "" {
+		s.releaseObject(checksum, []string{mediaKey(checksum)})
+	}
+	if thumbnail != "" {
+		keys := make([]string, 0, len(media.ThumbnailSizes))
+		for size := range media.ThumbnailSizes {
+			keys = append(keys, thumbnailKey(thumbnail, size))
+		}
+		s.releaseObject(thumbnail, keys)
+	}
+}
+
+func (s *ContentService) releaseObject(checksum string, keys []string) {
+	inUse, err := s.repo.ObjectInUse(checksum)
+	if err != nil {
+		log.Printf("⚠️ Не удалось проверить использование файла %s: %v", checksum, err)
+		return
+	}
+	if inUse {
+		return
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(key); err != nil {
+			log.Printf("⚠️ Не удалось удалить %s из хранилища: %v", key, err)
+		}
+	}
+}
+
+func (s *ContentService) getByID(id uint) (*model.Content, error) {
+	content, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrContentNotFound
+	}
+	return content, err
+}
+
+// validate проверяет контент по правилам его вида и существование папки и локации
+func (s *ContentService) validate(content *model.Content) error {
+	if err := validateContent(content, s.allowedHosts); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+	content.Tags = normalizeTags(content.Tags)
+	if content.FolderID != nil {
+		exists, err := s.repo.FolderExists(*content.FolderID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: folder %d not found", ErrInvalidContent, *content.FolderID)
+		}
+	}
+	if content.LocationID != nil {
+		exists, err := s.repo.LocationExists(*content.LocationID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: location %d not found", ErrInvalidContent, *content.LocationID)
+		}
+	}
+	return nil
+}
+
+// MaxUploadSize — максимальный размер файла контента в байтах
+func (s *ContentService) MaxUploadSize() int64 {
+	return s.maxUploadSize
+}
+
+// MaxRequestSize — предел тела multipart-запроса: файл плюс запас на поля формы
+func (s *ContentService) MaxRequestSize() int64 {
+	return s.maxUploadSize + 1<<20
+}
+
+// StoreFile потоково пишет файл во временный файл, считая размер и SHA-256,
+// определяет MIME по содержимому и кладёт файл в хранилище под именем по хэшу
+func (s *ContentService) StoreFile(r io.Reader) (*StoredFile, error) {
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, s.maxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > s.maxUploadSize {
+		return nil, ErrFileTooLarge
+	}
+	return s.storeLocal(tmp, hex.EncodeToString(hash.Sum(nil)), size)
+}
+
+// StoreLocalFile кладёт в хранилище уже полностью записанный на диск файл
+// (например, собранный из частей возобновляемой загрузки)
+func (s *ContentService) StoreLocalFile(f *os.File) (*StoredFile, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, err
+	}
+	if size > s.maxUploadSize {
+		return nil, ErrFileTooLarge
+	}
+	return s.storeLocal(f, hex.EncodeToString(hash.Sum(nil)), size)
+}
+
+func (s *ContentService) storeLocal(f *os.File, checksum string, size int64) (*StoredFile, error) {
+	if size == 0 {
+		return nil, errors.New("file is empty")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	mime, err := mimetype.DetectReader(f)
+	if err != nil {
+		return nil, err
+	}
+	stored := &StoredFile{Checksum: checksum, Size: size, MimeType: mime.String()}
+	if info, err := media.Probe(f, size, stored.MimeType); err == nil {
+		stored.Media = info
+	} else if !errors.Is(err, media.ErrUnsupported) {
+		log.Printf("⚠️ Не удалось извлечь метаданные (%s): %v", stored.MimeType, err)
+	}
+
+	key := mediaKey(stored.Checksum)
+	exists, err := storage.Exists(s.store, key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := s.store.Put(key, f, size, stored.MimeType); err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.HasPrefix(stored.MimeType, "image/") {
+		if err := s.storeThumbnails(f, size, checksum); err != nil {
+			log.Printf("⚠️ Не удалось построить превью (%s): %v", stored.MimeType, err)
+		} else {
+			stored.Thumbnail = checksum
+		}
+	}
+	return stored, nil
+}
+
+// StorePoster сохраняет постер видео: по нему строятся превью контента.
+// Возвращает SHA-256 постера для поля Content.Thumbnail.
+func (s *ContentService) StorePoster(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPosterSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxPosterSize {
+		return "", ErrFileTooLarge
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	if err := s.storeThumbnails(bytes.NewReader(data), int64(len(data)), checksum); err != nil {
+		return "", fmt.Errorf("invalid poster image: %w", err)
+	}
+	return checksum, nil
+}
+
+// storeThumbnails строит превью всех размеров, если их ещё нет в хранилище
+func (s *ContentService) storeThumbnails(r io.ReaderAt, size int64, checksum string) error {
+	exists, err := storage.Exists(s.store, thumbnailKey(checksum, media.DefaultThumbnailSize))
+	if err != nil || exists {
+		return err
+	}
+	thumbs, err := media.Thumbnails(r, size)
+	if err != nil {
+		return err
+	}
+	for name, data := range thumbs {
+		if err := s.store.Put(thumbnailKey(checksum, name), bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateUploaded создаёт запись контента для уже сохранённого файла; файл становится версией 1.
+// Постер, если есть, уже записан в content.Thumbnail. При ошибке файл и постер удаляются из хранилища.
+func (s *ContentService) CreateUploaded(content *model.Content, file *StoredFile, uploadedBy string) error {
+	poster := content.Thumbnail
+	err := s.prepareUploaded(content, file)
+	if err == nil {
+		err = s.withQuota(content.LocationID, file, func(tx *repository.ContentRepository) error {
+			return saveUploaded(tx, content, uploadedBy)
+		})
+	}
+	if err != nil {
+		s.ReleaseUpload(file, poster)
+	}
+	return err
+}
+
+// ReleaseUpload удаляет из хранилища файл и постер неудавшейся загрузки,
+// если на них не ссылается другой контент; file может быть nil, poster — пустым
+func (s *ContentService) ReleaseUpload(file *StoredFile, poster string) {
+	if file != nil {
+		s.releaseObjects(file.Checksum, file.Thumbnail)
+	}
+	if file == nil || poster != file.Thumbnail {
+		s.releaseObjects("", poster)
+	}
+}
+
+// prepareUploaded заполняет контент сведениями о файле и проверяет его
+func (s *ContentService) prepareUploaded(content *model.Content, file *StoredFile) error {
+	content.Size = file.Size
+	content.Checksum = file.Checksum
+	content.MimeType = file.MimeType
+	applyMediaInfo(content, file.Media)
+	if content.Thumbnail == "" {
+		content.Thumbnail = file.Thumbnail
+	}
+	t, err := contentTypeForMime(file.MimeType)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+	content.Type = t
+	if err := s.validate(content); err != nil {
+		return err
+	}
+	resetReview(content)
+	return nil
+}
+
+// saveUploaded создаёт подготовленный контент: путь к файлу зависит от ID,
+// поэтому запись сохраняется дважды, второй раз — вместе с версией 1
+func saveUploaded(repo *repository.ContentRepository, content *model.Content, uploadedBy string) error {
+	if err := repo.Create(content); err != nil {
+		return err
+	}
+	content.Path = fmt.Sprintf("/api/v1/contents/%d/download", content.ID)
+	content.Version = 1
+	return repo.UpdateWithVersion(content, newContentVersion(content, uploadedBy))
+}
+
+// ReplaceFile заменяет файл существующего контента и его превью, сохраняя прежний файл
+// как предыдущую версию. poster — SHA-256 нового постера; если пусто, превью строится по самому файлу.
+// При ошибке новый файл и постер удаляются из хранилища.
+func (s *ContentService) ReplaceFile(id uint, file *StoredFile, poster, uploadedBy string) (*model.Content, error) {
+	content, err := s.replaceFile(id, file, poster, uploadedBy)
+	if err != nil {
+		s.ReleaseUpload(file, poster)
+		return nil, err
+	}
+	return content, nil
+}
+
+func (s *ContentService) replaceFile(id uint, file *StoredFile, poster, uploadedBy string) (*model.Content, error) {
+	content, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	t, err := contentTypeForMime(file.MimeType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+	content.Size = file.Size
+	content.Checksum = file.Checksum
+	content.MimeType = file.MimeType
+	content.Type = t
+	content.MediaDuration, content.Width, content.Height, content.Orientation, content.Codec = 0, 0, 0, "", ""
+	applyMediaInfo(content, file.Media)
+	content.Thumbnail = file.Thumbnail
+	if poster != "" {
+		content.Thumbnail = poster
+	}
+	content.Path = fmt.Sprintf("/api/v1/contents/%d/download", content.ID)
+	keepFitOnlyForImages(content)
+	if err := validateContent(content, s.allowedHosts); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+	resetReview(content)
+	err = s.withQuota(content.LocationID, file, func(tx *repository.ContentRepository) error {
+		return s.saveNewVersion(tx, content, uploadedBy, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// withQuota сохраняет контент с новым файлом: fn выполняется в одной транзакции с проверкой
+// квоты локации. Удалить файл при ошибке должен вызывающий.
+func (s *ContentService) withQuota(locationID *uint, file *StoredFile, fn func(tx *repository.ContentRepository) error) error {
+	if s.storage == nil {
+		return fn(s.repo)
+	}
+	return s.repo.Transaction(func(tx *repository.ContentRepository) error {
+		if err := s.storage.CheckQuotaTx(tx, locationID, file); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// checkMoveQuota проверяет в транзакции переноса, поместятся ли все версии файла
+// контента в квоту новой локации
+func (s *ContentService) checkMoveQuota(tx *repository.ContentRepository, content *model.Content) error {
+	if s.storage == nil {
+		return nil
+	}
+	versions, err := tx.GetVersions(content.ID)
+	if err != nil {
+		return err
+	}
+	files := make([]*StoredFile, 0, len(versions))
+	for _, v := range versions {
+		files = append(files, &StoredFile{Checksum: v.Checksum, Size: v.Size})
+	}
+	return s.storage.CheckQuotaTx(tx, content.LocationID, files...)
+}
+
+func sameLocation(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// keepFitOnlyForImages сбрасывает подгонку под экран, если файл заменён не изображением
+func keepFitOnlyForImages(content *model.Content) {
+	if content.Type != model.ContentTypeImage {
+		content.FitMode, content.FocusX, content.FocusY = "", nil, nil
+	}
+}
+
+// SetPoster назначает контенту постер, ранее сохранённый через StorePoster.
+// Если назначить не удалось, постер удаляется из хранилища.
+func (s *ContentService) SetPoster(id uint, poster string) (*model.Content, error) {
+	content, err := s.repo.GetByID(id)
+	if err != nil {
+		s.ReleaseUpload(nil, poster)
+		return nil, err
+	}
+	oldThumbnail := content.Thumbnail
+	content.Thumbnail = poster
+	if err := s.repo.Update(content); err != nil {
+		s.ReleaseUpload(nil, poster)
+		return nil, err
+	}
+	if content.Version > 0 {
+		if err := s.repo.SetVersionThumbnail(content.ID, content.Version, poster); err != nil {
+			s.ReleaseUpload(nil, poster)
+			return nil, err
+		}
+	}
+	s.releaseObjects("", oldThumbnail)
+	return content, nil
+}
+
+// OpenThumbnail открывает превью контента размера size (small | medium | large)
+func (s *ContentService) OpenThumbnail(id uint, size string) (*model.Content, io.ReadSeekCloser, error) {
+	if _, ok := media.ThumbnailSizes[size]; !ok {
+		return nil, nil, fmt.Errorf("unknown thumbnail size %q", size)
+	}
+	content, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if content.Thumbnail == "" {
+		return nil, nil, errors.New("content has no thumbnail")
+	}
+	f, err := s.store.Get(thumbnailKey(content.Thumbnail, size))
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, f, nil
+}
+
+// OpenFile открывает сохранённый файл контента
+func (s *ContentService) OpenFile(id uint) (*model.Content, io.ReadSeekCloser, error) {
+	content, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if content.Checksum == "" {
+		return nil, nil, errors.New("content has no uploaded file")
+	}
+	f, err := s.store.Get(mediaKey(content.Checksum))
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, f, nil
+}
+
+// PresignURL выдаёт временную прямую ссылку на файл контента
+func (s *ContentService) PresignURL(id uint, ttl time.Duration) (string, error) {
+	content, err := s.repo.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	if content.Checksum == "" {
+		return "", errors.New("content has no uploaded file")
+	}
+	return s.store.Presign(mediaKey(content.Checksum), ttl)
+}
+
+// normalizeTags приводит теги к нижнему регистру, убирает пустые и повторы
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// applyMediaInfo переносит извлечённые метаданные в контент
+func applyMediaInfo(content *model.Content, info *media.Info) {
+	if info == nil {
+		return
+	}
+	content.MediaDuration = info.DurationSeconds()
+	content.Width = info.Width
+	content.Height = info.Height
+	content.Orientation = info.Orientation
+	content.Codec = info.Codec
+}
+
+// mediaKey — имя файла в хранилище по SHA-256 содержимого
+func mediaKey(checksum string) string {
+	return "media/" + checksum[:2] + "/" + checksum
 }
 
-func (s *ContentService) Delete(id uint) error {
-	return s.repo.Delete(id)
+// thumbnailKey — имя превью в хранилище по SHA-256 источника
+func thumbnailKey(checksum, size string) string {
+	return "thumbnails/" + checksum[:2] + "/" + checksum + "/" + size + ".jpg"
 }
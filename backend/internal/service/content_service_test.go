@@ -0,0 +1,72 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TryHanger/digital_signage/backend/internal/media"
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunDB — gorm без подключения к базе: запросы только формируются, выборки пусты
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestFailedUploadReleasesObjects(t *testing.T) {
+	checksum, thumbnail, poster := strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)
+	keys := []string{mediaKey(checksum)}
+	for size := range media.ThumbnailSizes {
+		keys = append(keys, thumbnailKey(thumbnail, size), thumbnailKey(poster, size))
+	}
+	tests := []struct {
+		name   string
+		upload func(s *ContentService, file *StoredFile) error
+	}{
+		{"create with unsupported type", func(s *ContentService, file *StoredFile) error {
+			return s.CreateUploaded(&model.Content{Title: "Архив", Thumbnail: poster}, file, "editor")
+		}},
+		{"replace with unsupported type", func(s *ContentService, file *StoredFile) error {
+			_, err := s.ReplaceFile(1, file, poster, "editor")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := storage.NewLocal(t.TempDir(), "http://signage.local/", "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range keys {
+				if err := store.Put(key, strings.NewReader("data"), 4, "application/octet-stream"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := &ContentService{repo: repository.NewContentRepository(dryRunDB(t)), store: store}
+			file := &StoredFile{Checksum: checksum, Size: 4, MimeType: "application/zip", Thumbnail: thumbnail}
+
+			if err := tt.upload(s, file); err == nil {
+				t.Fatal("upload succeeded, want unsupported file type")
+			}
+			for _, key := range keys {
+				if exists, err := storage.Exists(store, key); err != nil || exists {
+					t.Fatalf("%s still stored (err %v)", key, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrVersionNotFound = errors.New("content version not found")
+
+// VersionChange — поле, которым различаются две версии файла
+type VersionChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Versions возвращает версии файла контента, от новых к старым
+func (s *ContentService) Versions(id uint) ([]model.ContentVersion, error) {
+	if _, err := s.getByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetVersions(id)
+}
+
+// Rollback делает текущим файл версии version. Откат не переписывает историю:
+// создаётся новая версия с тем же файлом, поэтому плееры видят смену ревизии.
+func (s *ContentService) Rollback(id uint, version int, uploadedBy string) (*model.Content, error) {
+	content, err := s.getByID(id)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.getVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+	if version == content.Version {
+		return nil, fmt.Errorf("%w: version %d is already current", ErrInvalidContent, version)
+	}
+	t, err := contentTypeForMime(v.MimeType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+	content.Type = t
+	content.Size = v.Size
+	content.Checksum = v.Checksum
+	content.MimeType = v.MimeType
+	content.MediaDuration = v.MediaDuration
+	content.Width = v.Width
+	content.Height = v.Height
+	content.Orientation = v.Orientation
+	content.Codec = v.Codec
+	content.Thumbnail = v.Thumbnail
+	keepFitOnlyForImages(content)
+	if err := validateContent(content, s.allowedHosts); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+	resetReview(content)
+	if err := s.saveNewVersion(s.repo, content, uploadedBy, &version); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// DiffVersions сравнивает сведения о файле двух версий
+func (s *ContentService) DiffVersions(id uint, from, to int) ([]VersionChange, error) {
+	a, err := s.getVersion(id, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.getVersion(id, to)
+	if err != nil {
+		return nil, err
+	}
+	changes := []VersionChange{}
+	add := func(field string, x, y interface{}) {
+		if x != y {
+			changes = append(changes, VersionChange{Field: field, From: x, To: y})
+		}
+	}
+	add("checksum", a.Checksum, b.Checksum)
+	add("size", a.Size, b.Size)
+	add("mimeType", a.MimeType, b.MimeType)
+	add("mediaDuration", a.MediaDuration, b.MediaDuration)
+	add("width", a.Width, b.Width)
+	add("height", a.Height, b.Height)
+	add("orientation", a.Orientation, b.Orientation)
+	add("codec", a.Codec, b.Codec)
+	add("thumbnail", a.Thumbnail, b.Thumbnail)
+	add("uploadedBy", a.UploadedBy, b.UploadedBy)
+	return changes, nil
+}
+
+// saveNewVersion сохраняет контент с файлом как следующую версию; номер версии
+// назначает репозиторий в той же транзакции
+func (s *ContentService) saveNewVersion(repo *repository.ContentRepository, content *model.Content, uploadedBy string, restoredFrom *int) error {
+	v := newContentVersion(content, uploadedBy)
+	v.RestoredFrom = restoredFrom
+	return repo.SaveNextVersion(content, v)
+}
+
+func (s *ContentService) getVersion(id uint, version int) (*model.ContentVersion, error) {
+	v, err := s.repo.GetVersion(id, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionNotFound
+	}
+	return v, err
+}
+
+// newContentVersion — запись версии для текущего файла контента
+func newContentVersion(content *model.Content, uploadedBy string) *model.ContentVersion {
+	return &model.ContentVersion{
+		ContentID:     content.ID,
+		Version:       content.Version,
+		Size:          content.Size,
+		Checksum:      content.Checksum,
+		MimeType:      content.MimeType,
+		MediaDuration: content.MediaDuration,
+		Width:         content.Width,
+		Height:        content.Height,
+		Orientation:   content.Orientation,
+		Codec:         content.Codec,
+		Thumbnail:     content.Thumbnail,
+		UploadedBy:    uploadedBy,
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+var ErrDefaultPlaylistNotFound = errors.New("default playlist not found")
+
+type DefaultPlaylistService struct {
+	repo *repository.DefaultPlaylistRepository
+}
+
+func NewDefaultPlaylistService(repo *repository.DefaultPlaylistRepository) *DefaultPlaylistService {
+	return &DefaultPlaylistService{repo: repo}
+}
+
+func (s *DefaultPlaylistService) Create(playlist *model.DefaultPlaylist) error {
+	if err := s.validate(playlist, 0); err != nil {
+		return err
+	}
+	return s.repo.Create(playlist)
+}
+
+func (s *DefaultPlaylistService) GetAll() ([]model.DefaultPlaylist, error) {
+	return s.repo.GetAll()
+}
+
+func (s *DefaultPlaylistService) GetByID(id uint) (*model.DefaultPlaylist, error) {
+	playlist, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDefaultPlaylistNotFound
+	}
+	return playlist, err
+}
+
+func (s *DefaultPlaylistService) Update(playlist *model.DefaultPlaylist) error {
+	existing, err := s.GetByID(playlist.ID)
+	if err != nil {
+		return err
+	}
+	playlist.CreatedAt = existing.CreatedAt
+	if err := s.validate(playlist, playlist.ID); err != nil {
+		return err
+	}
+	err = s.repo.Update(playlist)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDefaultPlaylistNotFound
+	}
+	return err
+}
+
+func (s *DefaultPlaylistService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+// validate проверяет, что цель соответствует уровню и что для цели ещё нет плейлиста
+func (s *DefaultPlaylistService) validate(playlist *model.DefaultPlaylist, id uint) error {
+	hasLocation, hasGroup, hasMonitor := playlist.LocationID != nil, playlist.GroupID != nil, playlist.MonitorID != nil
+	switch playlist.Level {
+	case model.DefaultLevelGlobal:
+		if hasLocation || hasGroup || hasMonitor {
+			return errors.New("global default playlist must not have a target")
+		}
+	case model.DefaultLevelLocation:
+		if !hasLocation || hasGroup || hasMonitor {
+			return errors.New("location default playlist requires only locationId")
+		}
+	case model.DefaultLevelGroup:
+		if !hasGroup || hasLocation || hasMonitor {
+			return errors.New("group default playlist requires only groupId")
+		}
+	case model.DefaultLevelMonitor:
+		if !hasMonitor || hasLocation || hasGroup {
+			return errors.New("monitor default playlist requires only monitorId")
+		}
+	default:
+		return fmt.Errorf("unknown default playlist level %q", playlist.Level)
+	}
+
+	if len(playlist.Items) == 0 {
+		return errors.New("default playlist has no items")
+	}
+	for _, item := range playlist.Items {
+		if item.ContentID == 0 {
+			return errors.New("default playlist item requires contentId")
+		}
+	}
+
+	exists, err := s.repo.ExistsForTarget(playlist, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("default playlist for this %s already exists", playlist.Level)
+	}
+	return nil
+}
+
+// mostSpecificDefault выбирает плейлист самого специфичного уровня
+func mostSpecificDefault(playlists []model.DefaultPlaylist) *model.DefaultPlaylist {
+	rank := map[model.DefaultLevel]int{
+		model.DefaultLevelGlobal:   1,
+		model.DefaultLevelLocation: 2,
+		model.DefaultLevelGroup:    3,
+		model.DefaultLevelMonitor:  4,
+	}
+	var best *model.DefaultPlaylist
+	for i := range playlists {
+		if best == nil || rank[playlists[i].Level] > rank[best.Level] {
+			best = &playlists[i]
+		}
+	}
+	return best
+}
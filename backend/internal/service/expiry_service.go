@@ -0,0 +1,138 @@
+package service
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+)
+
+const (
+	DefaultExpiryWindow = 7 // дней
+	MaxExpiryWindow     = 90
+)
+
+// ExpiryService находит контент, период показа которого скоро закончится,
+// и блоки расписаний, которые после этого останутся пустыми
+type ExpiryService struct {
+	contentRepo  *repository.ContentRepository
+	scheduleRepo *repository.ScheduleRepository
+}
+
+func NewExpiryService(contentRepo *repository.ContentRepository, scheduleRepo *repository.ScheduleRepository) *ExpiryService {
+	return &ExpiryService{contentRepo: contentRepo, scheduleRepo: scheduleRepo}
+}
+
+// ExpiryReport — контент, истекающий в днях [From, Until], и блоки, которые из-за этого опустеют
+type ExpiryReport struct {
+	From        time.Time       `json:"from"`
+	Until       time.Time       `json:"until"`
+	Contents    []model.Content `json:"contents"`
+	EmptyBlocks []EmptyBlock    `json:"emptyBlocks"`
+}
+
+// EmptyBlock — блок расписания, в котором с EmptyFrom не останется ни одного элемента
+type EmptyBlock struct {
+	ScheduleID   uint      `json:"scheduleId"`
+	ScheduleName string    `json:"scheduleName"`
+	BlockID      uint      `json:"blockId"`
+	BlockName    string    `json:"blockName"`
+	EmptyFrom    time.Time `json:"emptyFrom"`
+	ContentIDs   []uint    `json:"contentIds"` // истекающий контент блока
+}
+
+// Start строит отчёт сразу и затем раз в сутки, записывая его в лог
+func (s *ExpiryService) Start() {
+	go func() {
+		s.logReport(time.Now())
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			s.logReport(now)
+		}
+	}()
+}
+
+func (s *ExpiryService) logReport(now time.Time) {
+	report, err := s.Report(now, DefaultExpiryWindow)
+	if err != nil {
+		log.Printf("❌ Ошибка проверки сроков показа контента: %v", err)
+		return
+	}
+	for _, c := range report.Contents {
+		log.Printf("⏳ Контент %d (%s) перестанет показываться после %s", c.ID, c.Title, c.ValidUntil.Format("2006-01-02"))
+	}
+	for _, b := range report.EmptyBlocks {
+		log.Printf("⚠️ Расписание %d (%s): блок %q останется пустым с %s", b.ScheduleID, b.ScheduleName, b.BlockName, b.EmptyFrom.Format("2006-01-02"))
+	}
+}
+
+// Report возвращает контент, истекающий в ближайшие days дней (включая сегодня),
+// и блоки расписаний, в которых после этого не останется элементов
+func (s *ExpiryService) Report(now time.Time, days int) (*ExpiryReport, error) {
+	from := dateOf(now)
+	until := from.AddDate(0, 0, days)
+
+	contents, err := s.contentRepo.GetExpiring(from, until)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.scheduleRepo.GetActiveBetween(from, until)
+	if err != nil {
+		return nil, err
+	}
+
+	expiring := make(map[uint]bool, len(contents))
+	for _, c := range contents {
+		expiring[c.ID] = true
+	}
+	report := &ExpiryReport{From: from, Until: until, Contents: contents, EmptyBlocks: []EmptyBlock{}}
+	for _, schedule := range schedules {
+		for _, block := range schedule.Blocks {
+			empty, ok := emptyBlock(block, expiring, from, until)
+			if !ok || (schedule.EndDate != nil && dateOf(*schedule.EndDate).Before(empty.EmptyFrom)) {
+				continue
+			}
+			empty.ScheduleID = schedule.ID
+			empty.ScheduleName = schedule.Name
+			report.EmptyBlocks = append(report.EmptyBlocks, empty)
+		}
+	}
+	return report, nil
+}
+
+// emptyBlock проверяет, опустеет ли блок в днях [from, until] из-за истекающего контента:
+// у каждого элемента должен быть последний день показа (свой или контента), и хотя бы
+// один элемент должен заканчиваться истечением контента из expiring
+func emptyBlock(block model.ScheduleBlock, expiring map[uint]bool, from, until time.Time) (EmptyBlock, bool) {
+	result := EmptyBlock{BlockID: block.ID, BlockName: block.Name}
+	var last time.Time
+	for _, item := range block.Items {
+		end := item.ValidUntil
+		if item.Content != nil && item.Content.ValidUntil != nil && (end == nil || item.Content.ValidUntil.Before(*end)) {
+			end = item.Content.ValidUntil
+		}
+		if end == nil {
+			return result, false
+		}
+		if expiring[item.ContentID] && !slices.Contains(result.ContentIDs, item.ContentID) {
+			result.ContentIDs = append(result.ContentIDs, item.ContentID)
+		}
+		if day := dateOf(*end); day.After(last) {
+			last = day
+		}
+	}
+	if len(result.ContentIDs) == 0 || last.Before(from) || last.After(until) {
+		return result, false
+	}
+	result.EmptyFrom = last.AddDate(0, 0, 1)
+	return result, true
+}
+
+// dateOf — календарный день t в UTC, как хранятся поля типа date
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/feed"
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultFeedRefresh  = 15 * time.Minute
+	minFeedRefresh      = time.Minute
+	defaultFeedItems    = 20
+	maxFeedItems        = 100
+	maxCachedFeedItems  = 200
+	maxFeedDocumentSize = 10 << 20
+)
+
+var ErrNotFeed = errors.New("content is not a feed")
+
+// FeedService периодически загружает RSS/Atom-ленты и кэширует записи в БД,
+// чтобы плееры получали их с сервера, а не напрямую у издателя
+type FeedService struct {
+	contentRepo *repository.ContentRepository
+	feedRepo    *repository.FeedRepository
+	client      *http.Client
+}
+
+func NewFeedService(contentRepo *repository.ContentRepository, feedRepo *repository.FeedRepository, client *http.Client) *FeedService {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &FeedService{contentRepo: contentRepo, feedRepo: feedRepo, client: client}
+}
+
+// FeedView — записи ленты для плеера: после фильтра и ограничения количества
+type FeedView struct {
+	ContentID uint             `json:"contentId"`
+	Title     string           `json:"title"`
+	FetchedAt *time.Time       `json:"fetchedAt"`
+	Items     []model.FeedItem `json:"items"`
+}
+
+// Start каждые tick обновляет ленты, у которых подошёл срок
+func (s *FeedService) Start(tick time.Duration) {
+	go func() {
+		s.RefreshDue(time.Now())
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			s.RefreshDue(now)
+		}
+	}()
+}
+
+// RefreshDue обновляет ленты, которые не загружались дольше своего RefreshInterval
+func (s *FeedService) RefreshDue(now time.Time) {
+	feeds, err := s.contentRepo.GetByTypes(model.ContentTypeFeed)
+	if err != nil {
+		log.Printf("❌ Ошибка загрузки списка лент: %v", err)
+		return
+	}
+	for i := range feeds {
+		c := &feeds[i]
+		if c.HealthCheckedAt != nil && now.Sub(*c.HealthCheckedAt) < feedRefreshInterval(c) {
+			continue
+		}
+		if err := s.refresh(c, now); err != nil {
+			log.Printf("⚠️ Не удалось обновить ленту %d (%s): %v", c.ID, c.Path, err)
+		}
+	}
+}
+
+// Refresh загружает ленту немедленно
+func (s *FeedService) Refresh(id uint) (*FeedView, error) {
+	c, err := s.feedContent(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh(c, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.GetItems(id)
+}
+
+// GetItems возвращает закэшированные записи ленты с учётом фильтра и FeedMaxItems
+func (s *FeedService) GetItems(id uint) (*FeedView, error) {
+	c, err := s.feedContent(id)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := s.feedRepo.GetItems(id)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := c.FeedMaxItems
+	if limit == 0 {
+		limit = defaultFeedItems
+	}
+	filter := feed.ParseFilter(c.FeedFilter)
+	view := &FeedView{ContentID: c.ID, Title: c.Title, FetchedAt: c.FeedFetchedAt, Items: []model.FeedItem{}}
+	for _, item := range cached {
+		if len(view.Items) >= limit {
+			break
+		}
+		if filter.Match(item.Title, item.Summary) {
+			view.Items = append(view.Items, item)
+		}
+	}
+	return view, nil
+}
+
+func (s *FeedService) feedContent(id uint) (*model.Content, error) {
+	c, err := s.contentRepo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrContentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Type != model.ContentTypeFeed {
+		return nil, ErrNotFeed
+	}
+	return c, nil
+}
+
+// refresh загружает и разбирает ленту, заменяет кэш и записывает результат в состояние контента.
+// При ошибке старые записи остаются: контент помечается недоступным, только если показывать нечего.
+func (s *FeedService) refresh(c *model.Content, now time.Time) error {
+	parsed, fetchErr := s.fetch(c.Path)
+	if fetchErr == nil {
+		items := make([]model.FeedItem, 0, min(len(parsed.Items), maxCachedFeedItems))
+		for i, it := range parsed.Items {
+			if i == maxCachedFeedItems {
+				break
+			}
+			item := model.FeedItem{
+				ContentID: c.ID,
+				GUID:      it.GUID,
+				Title:     it.Title,
+				Summary:   it.Summary,
+				Link:      it.Link,
+				ImageURL:  it.Image,
+				Position:  i,
+			}
+			if !it.Published.IsZero() {
+				published := it.Published
+				item.PublishedAt = &published
+			}
+			items = append(items, item)
+		}
+		if err := s.feedRepo.ReplaceItems(c.ID, items); err != nil {
+			return err
+		}
+		if err := s.contentRepo.MarkFeedFetched(c.ID, now); err != nil {
+			return err
+		}
+		return s.contentRepo.UpdateHealth(c.ID, model.ContentHealthy, "", now)
+	}
+
+	status := model.ContentUnhealthy
+	if c.FeedFetchedAt != nil {
+		status = model.ContentHealthy // показываем последние успешно загруженные записи
+	}
+	if err := s.contentRepo.UpdateHealth(c.ID, status, fetchErr.Error(), now); err != nil {
+		return err
+	}
+	return fetchErr
+}
+
+func (s *FeedService) fetch(url string) (*feed.Feed, error) {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	// в отличие от io.LimitReader, MaxBytesReader не обрывает документ молча,
+	// а возвращает ошибку, по которой видно, что лента слишком большая
+	f, err := feed.Parse(http.MaxBytesReader(nil, resp.Body, maxFeedDocumentSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, fmt.Errorf("feed document exceeds %d bytes", maxFeedDocumentSize)
+	}
+	return f, err
+}
+
+func feedRefreshInterval(c *model.Content) time.Duration {
+	if c.RefreshInterval > 0 {
+		return time.Duration(c.RefreshInterval) * time.Second
+	}
+	return defaultFeedRefresh
+}
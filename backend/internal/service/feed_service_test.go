@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFeedFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rss", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>Новости</title><item><title>Запись</title><guid>1</guid></item></channel></rss>`)
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusBadGateway)
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		// валидная лента, которая не помещается в maxFeedDocumentSize
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>Много</title>`)
+		item := "<item><title>" + strings.Repeat("x", 1000) + "</title></item>"
+		for written := 0; written <= maxFeedDocumentSize; written += len(item) {
+			if _, err := fmt.Fprint(w, item); err != nil {
+				return
+			}
+		}
+		fmt.Fprint(w, `</channel></rss>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	s := NewFeedService(nil, nil, srv.Client())
+
+	f, err := s.fetch(srv.URL + "/rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Новости" || len(f.Items) != 1 {
+		t.Fatalf("feed = %+v", f)
+	}
+
+	if _, err := s.fetch(srv.URL + "/down"); err == nil || err.Error() != "HTTP 502" {
+		t.Fatalf("err = %v, want HTTP 502", err)
+	}
+	if _, err := s.fetch(srv.URL + "/huge"); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("err = %v, want the size error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+)
+
+type FolderService struct {
+	repo *repository.FolderRepository
+}
+
+func NewFolderService(repo *repository.FolderRepository) *FolderService {
+	return &FolderService{repo: repo}
+}
+
+func (s *FolderService) Create(folder *model.Folder) error {
+	if err := s.validate(folder); err != nil {
+		return err
+	}
+	return s.repo.Create(folder)
+}
+
+func (s *FolderService) GetAll() ([]model.Folder, error) {
+	return s.repo.GetAll()
+}
+
+func (s *FolderService) GetByID(id uint) (*model.Folder, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *FolderService) Update(folder *model.Folder) error {
+	if _, err := s.repo.GetByID(folder.ID); err != nil {
+		return err
+	}
+	if err := s.validate(folder); err != nil {
+		return err
+	}
+	return s.repo.Update(folder)
+}
+
+func (s *FolderService) Delete(id uint) error {
+	folder, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(folder)
+}
+
+// validate проверяет имя, существование родителя и отсутствие циклов в дереве папок
+func (s *FolderService) validate(folder *model.Folder) error {
+	folder.Name = strings.TrimSpace(folder.Name)
+	if folder.Name == "" {
+		return errors.New("folder name is required")
+	}
+	if strings.Contains(folder.Name, "/") {
+		return errors.New("folder name must not contain '/'")
+	}
+
+	// поднимаемся от родителя к корню: папка не может оказаться собственным предком
+	for parentID := folder.ParentID; parentID != nil; {
+		if folder.ID != 0 && *parentID == folder.ID {
+			return errors.New("folder cannot be moved into itself or its subfolder")
+		}
+		parent, err := s.repo.GetByID(*parentID)
+		if err != nil {
+			return fmt.Errorf("parent folder %d not found", *parentID)
+		}
+		parentID = parent.ParentID
+	}
+
+	exists, err := s.repo.ExistsName(folder.ParentID, folder.Name, folder.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("folder %q already exists", folder.Name)
+	}
+	return nil
+}
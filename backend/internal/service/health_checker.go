@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+)
+
+const (
+	healthCheckTimeout = 10 * time.Second
+	healthCheckWorkers = 8
+)
+
+// errNotProbed — адрес нельзя проверить (udp, srt): состояние остаётся неизвестным
+var errNotProbed = errors.New("protocol cannot be probed")
+
+// Порты по умолчанию для потоковых протоколов, проверяемых TCP-подключением
+var streamPorts = map[string]string{
+	"rtsp":  "554",
+	"rtsps": "322",
+	"rtmp":  "1935",
+	"rtmps": "443",
+}
+
+// HealthChecker периодически проверяет доступность веб-страниц и трансляций
+// и помечает недоступный контент; такой контент пропускается при сборке плейлиста
+type HealthChecker struct {
+	repo   *repository.ContentRepository
+	client *http.Client
+	dialer *net.Dialer
+}
+
+func NewHealthChecker(repo *repository.ContentRepository, client *http.Client) *HealthChecker {
+	if client == nil {
+		client = &http.Client{Timeout: healthCheckTimeout}
+	}
+	return &HealthChecker{repo: repo, client: client, dialer: &net.Dialer{Timeout: healthCheckTimeout}}
+}
+
+// Start запускает проверку сразу и затем каждые interval
+func (h *HealthChecker) Start(interval time.Duration) {
+	go func() {
+		h.CheckAll()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			h.CheckAll()
+		}
+	}()
+}
+
+// CheckAll проверяет весь веб-контент и трансляции, не более healthCheckWorkers одновременно
+func (h *HealthChecker) CheckAll() {
+	contents, err := h.repo.GetByTypes(model.ContentTypeWeb, model.ContentTypeStream)
+	if err != nil {
+		log.Printf("❌ Ошибка загрузки контента для проверки доступности: %v", err)
+		return
+	}
+
+	jobs := make(chan model.Content)
+	var wg sync.WaitGroup
+	for i := 0; i < healthCheckWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				h.checkAndSave(c)
+			}
+		}()
+	}
+	for _, c := range contents {
+		jobs <- c
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (h *HealthChecker) checkAndSave(c model.Content) {
+	err := h.Check(&c)
+	if errors.Is(err, errNotProbed) {
+		return
+	}
+	status, message := model.ContentHealthy, ""
+	if err != nil {
+		status, message = model.ContentUnhealthy, err.Error()
+	}
+	if err != nil && c.HealthStatus != model.ContentUnhealthy {
+		log.Printf("⚠️ Контент %d (%s) недоступен: %v", c.ID, c.Path, err)
+	} else if err == nil && c.HealthStatus == model.ContentUnhealthy {
+		log.Printf("✅ Контент %d (%s) снова доступен", c.ID, c.Path)
+	}
+	if err := h.repo.UpdateHealth(c.ID, status, message, time.Now()); err != nil {
+		log.Printf("❌ Не удалось сохранить состояние контента %d: %v", c.ID, err)
+	}
+}
+
+// Check проверяет адрес контента: HTTP(S) — запросом GET (для HLS ещё и заголовок
+// плейлиста), RTSP/RTMP — TCP-подключением к серверу
+func (h *HealthChecker) Check(c *model.Content) error {
+	u, err := url.Parse(c.Path)
+	if err != nil {
+		return err
+	}
+	switch scheme := strings.ToLower(u.Scheme); scheme {
+	case "http", "https":
+		return h.checkHTTP(u)
+	case "rtsp", "rtsps", "rtmp", "rtmps":
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), streamPorts[scheme])
+		}
+		conn, err := h.dialer.Dial("tcp", host)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	default:
+		return errNotProbed
+	}
+}
+
+func (h *HealthChecker) checkHTTP(u *url.URL) error {
+	resp, err := h.client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if strings.HasSuffix(strings.ToLower(u.Path), ".m3u8") {
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("empty HLS playlist: %w", err)
+		}
+		if !strings.HasPrefix(strings.TrimPrefix(line, "\ufeff"), "#EXTM3U") {
+			return errors.New("not an HLS playlist")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+func healthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html></html>")
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/moved-away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/missing", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/live.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "\ufeff#EXTM3U\n#EXT-X-VERSION:3\n")
+	})
+	mux.HandleFunc("/page.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html>not found</html>")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHealthCheckHTTP(t *testing.T) {
+	srv := healthServer(t)
+	h := NewHealthChecker(nil, &http.Client{Timeout: 200 * time.Millisecond})
+
+	tests := []struct {
+		path    string
+		wantErr string // подстрока ошибки; пусто — адрес доступен
+	}{
+		{"/ok", ""},
+		{"/down", "HTTP 503"},
+		{"/missing", "HTTP 404"},
+		{"/slow", "Client.Timeout"},
+		{"/moved", ""},
+		{"/moved-away", "HTTP 404"},
+		{"/loop", "stopped after 10 redirects"},
+		{"/live.m3u8", ""},
+		{"/page.m3u8", "not an HLS playlist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := h.Check(&model.Content{Path: srv.URL + tt.path})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("err = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHealthCheckServerGone(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	h := NewHealthChecker(nil, nil)
+	if err := h.Check(&model.Content{Path: addr + "/ok"}); err == nil {
+		t.Fatal("closed server reported as available")
+	}
+}
+
+func TestHealthCheckStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	h := NewHealthChecker(nil, nil)
+	if err := h.Check(&model.Content{Path: "rtsp://" + addr + "/stream"}); err != nil {
+		t.Fatalf("listening server: %v", err)
+	}
+	ln.Close()
+	if err := h.Check(&model.Content{Path: "rtmp://" + addr + "/live"}); err == nil {
+		t.Fatal("closed port reported as available")
+	}
+	if err := h.Check(&model.Content{Path: "udp://239.0.0.1:1234"}); !errors.Is(err, errNotProbed) {
+		t.Fatalf("udp err = %v, want errNotProbed", err)
+	}
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxImportFiles   = 500
+	maxManifestSize  = 1 << 20
+	manifestJSONName = "manifest.json"
+	manifestCSVName  = "manifest.csv"
+)
+
+// Итог импорта файла
+const (
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+var ErrInvalidArchive = errors.New("invalid import archive")
+
+// ImportService создаёт контент из ZIP-архива с файлами кампании.
+// Описание файлов берётся из manifest.json или manifest.csv — в архиве или отдельным файлом.
+type ImportService struct {
+	contents       *ContentService
+	maxArchiveSize int64
+}
+
+func NewImportService(contents *ContentService, maxArchiveSize int64) *ImportService {
+	return &ImportService{contents: contents, maxArchiveSize: maxArchiveSize}
+}
+
+// ImportOptions — параметры, общие для всех файлов архива
+type ImportOptions struct {
+	FolderID   *uint
+	LocationID *uint
+	UploadedBy string
+	// Manifest — манифест, переданный отдельно от архива; ManifestName — его имя файла
+	Manifest     []byte
+	ManifestName string
+}
+
+// ImportResult — итог по одному файлу архива
+type ImportResult struct {
+	File      string `json:"file"`
+	Status    string `json:"status"` // created | skipped | failed
+	ContentID uint   `json:"contentId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Created int            `json:"created"`
+	Skipped int            `json:"skipped"`
+	Failed  int            `json:"failed"`
+	Results []ImportResult `json:"results"`
+}
+
+// manifestEntry — описание файла в манифесте
+type manifestEntry struct {
+	File        string   `json:"file"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Duration    int      `json:"duration"`
+	Tags        []string `json:"tags"`
+	ValidFrom   string   `json:"validFrom"`
+	ValidUntil  string   `json:"validUntil"`
+}
+
+// MaxArchiveSize — предел размера архива в байтах
+func (s *ImportService) MaxArchiveSize() int64 {
+	return s.maxArchiveSize
+}
+
+// MaxManifestSize — предел размера манифеста в байтах
+func (s *ImportService) MaxManifestSize() int64 {
+	return maxManifestSize
+}
+
+// Import создаёт контент для каждого файла архива. Файлы, уже имеющиеся в библиотеке
+// или повторяющиеся в архиве (по SHA-256), пропускаются; файлы с ошибками попадают
+// в отчёт, как и файлы, не поместившиеся в квоту локации; все остальные записи
+// создаются одной транзакцией.
+func (s *ImportService) Import(archive io.ReaderAt, size int64, opts ImportOptions) (*ImportReport, error) {
+	zr, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	var files []*zip.File
+	manifestName, manifestData := opts.ManifestName, opts.Manifest
+	for _, f := range zr.File {
+		name := path.Clean(f.Name)
+		switch {
+		case f.FileInfo().IsDir(), strings.HasPrefix(name, "__MACOSX/"), strings.HasPrefix(path.Base(name), "."):
+		case strings.EqualFold(name, manifestJSONName) || strings.EqualFold(name, manifestCSVName):
+			if manifestData == nil {
+				if manifestData, err = readZipFile(f, maxManifestSize); err != nil {
+					return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+				}
+				manifestName = name
+			}
+		default:
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: archive has no files", ErrInvalidArchive)
+	}
+	if len(files) > maxImportFiles {
+		return nil, fmt.Errorf("%w: archive has %d files, at most %d allowed", ErrInvalidArchive, len(files), maxImportFiles)
+	}
+	manifest, err := parseManifest(manifestName, manifestData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	report := &ImportReport{Results: make([]ImportResult, len(files))}
+	var pending []*model.Content
+	var pendingIdx []int
+	var pendingFiles []*StoredFile  // для проверки квоты вместе с уже принятыми файлами
+	seen := make(map[string]string) // checksum -> файл архива
+	used := make(map[string]bool)   // использованные записи манифеста
+
+	for i, f := range files {
+		name := path.Clean(f.Name)
+		result := &report.Results[i]
+		result.File = name
+
+		entry, key := lookupManifest(manifest, name)
+		if key != "" {
+			used[key] = true
+		}
+		content, err := s.importFile(f, entry, opts)
+		if err != nil {
+			result.Status, result.Error = ImportFailed, err.Error()
+			continue
+		}
+		if first, ok := seen[content.Checksum]; ok {
+			result.Status, result.Error = ImportSkipped, "duplicate of "+first
+			continue
+		}
+		seen[content.Checksum] = name
+		existing, err := s.contents.repo.GetByChecksum(content.Checksum)
+		if err == nil {
+			result.Status, result.ContentID, result.Error = ImportSkipped, existing.ID, "file already in library"
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		file := &StoredFile{Checksum: content.Checksum, Size: content.Size, Thumbnail: content.Thumbnail}
+		if s.contents.storage != nil {
+			err := s.contents.storage.CheckQuota(opts.LocationID, append(pendingFiles, file)...)
+			if errors.Is(err, ErrQuotaExceeded) {
+				s.contents.releaseObjects(content.Checksum, content.Thumbnail)
+				result.Status, result.Error = ImportFailed, err.Error()
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		pending = append(pending, content)
+		pendingIdx = append(pendingIdx, i)
+		pendingFiles = append(pendingFiles, file)
+	}
+
+	err = s.contents.repo.Transaction(func(tx *repository.ContentRepository) error {
+		// повторная проверка под блокировкой локации: квоту могли занять параллельные загрузки
+		if s.contents.storage != nil {
+			if err := s.contents.storage.CheckQuotaTx(tx, opts.LocationID, pendingFiles...); err != nil {
+				return err
+			}
+		}
+		for _, content := range pending {
+			if err := saveUploaded(tx, content, opts.UploadedBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, content := range pending {
+			s.contents.releaseObjects(content.Checksum, content.Thumbnail)
+		}
+		return nil, err
+	}
+	for j, content := range pending {
+		result := &report.Results[pendingIdx[j]]
+		result.Status, result.ContentID = ImportCreated, content.ID
+	}
+
+	missing := make([]string, 0, len(manifest))
+	for key := range manifest {
+		if !used[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		report.Results = append(report.Results, ImportResult{File: name, Status: ImportFailed, Error: "file not found in archive"})
+	}
+	for _, r := range report.Results {
+		switch r.Status {
+		case ImportCreated:
+			report.Created++
+		case ImportSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// importFile сохраняет файл архива в хранилище и готовит для него запись контента
+func (s *ImportService) importFile(f *zip.File, entry *manifestEntry, opts ImportOptions) (*model.Content, error) {
+	if f.UncompressedSize64 > uint64(s.contents.MaxUploadSize()) {
+		return nil, ErrFileTooLarge
+	}
+	base := path.Base(f.Name)
+	content := &model.Content{Title: strings.TrimSuffix(base, path.Ext(base)), FolderID: opts.FolderID, LocationID: opts.LocationID}
+	if entry != nil {
+		if entry.Title != "" {
+			content.Title = entry.Title
+		}
+		content.Description = entry.Description
+		content.Duration = entry.Duration
+		content.Tags = entry.Tags
+		var err error
+		if content.ValidFrom, err = parseManifestDate(entry.ValidFrom); err != nil {
+			return nil, err
+		}
+		if content.ValidUntil, err = parseManifestDate(entry.ValidUntil); err != nil {
+			return nil, err
+		}
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	stored, err := s.contents.StoreFile(rc)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.contents.prepareUploaded(content, stored); err != nil {
+		s.contents.releaseObjects(stored.Checksum, stored.Thumbnail)
+		return nil, err
+	}
+	return content, nil
+}
+
+// lookupManifest ищет запись по пути в архиве, а если её нет — по имени файла
+func lookupManifest(manifest map[string]*manifestEntry, name string) (*manifestEntry, string) {
+	if entry, ok := manifest[name]; ok {
+		return entry, name
+	}
+	base := path.Base(name)
+	if entry, ok := manifest[base]; ok {
+		return entry, base
+	}
+	return nil, ""
+}
+
+// parseManifest разбирает manifest.json (массив записей) или manifest.csv
+// (заголовок: file,title,description,duration,tags,validFrom,validUntil; теги через ";")
+func parseManifest(name string, data []byte) (map[string]*manifestEntry, error) {
+	manifest := make(map[string]*manifestEntry)
+	if data == nil {
+		return manifest, nil
+	}
+
+	var entries []manifestEntry
+	switch ext := strings.ToLower(path.Ext(name)); {
+	case ext == ".json" || (ext == "" && bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))):
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("manifest: %v", err)
+		}
+	default:
+		var err error
+		if entries, err = parseManifestCSV(data); err != nil {
+			return nil, fmt.Errorf("manifest: %v", err)
+		}
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		e.File = path.Clean(strings.TrimSpace(e.File))
+		if e.File == "." {
+			return nil, fmt.Errorf("manifest: entry %d has no file", i+1)
+		}
+		if _, ok := manifest[e.File]; ok {
+			return nil, fmt.Errorf("manifest: file %q is listed twice", e.File)
+		}
+		manifest[e.File] = e
+	}
+	return manifest, nil
+}
+
+func parseManifestCSV(data []byte) ([]manifestEntry, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for i, h := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := columns["file"]; !ok {
+		return nil, errors.New(`CSV header must contain a "file" column`)
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[strings.ToLower(column)]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	entries := make([]manifestEntry, 0, len(records)-1)
+	for line, record := range records[1:] {
+		e := manifestEntry{
+			File:        field(record, "file"),
+			Title:       field(record, "title"),
+			Description: field(record, "description"),
+			ValidFrom:   field(record, "validFrom"),
+			ValidUntil:  field(record, "validUntil"),
+		}
+		if d := field(record, "duration"); d != "" {
+			if e.Duration, err = strconv.Atoi(d); err != nil {
+				return nil, fmt.Errorf("line %d: invalid duration %q", line+2, d)
+			}
+		}
+		if tags := field(record, "tags"); tags != "" {
+			e.Tags = strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' })
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// parseManifestDate принимает YYYY-MM-DD или RFC3339; пустая строка — без ограничения
+func parseManifestDate(v string) (*time.Time, error) {
+	if v = strings.TrimSpace(v); v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", v)
+	}
+	return &t, nil
+}
+
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New("file is too large")
+	}
+	return data, nil
+}
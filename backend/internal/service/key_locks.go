@@ -0,0 +1,42 @@
+package service
+
+import "sync"
+
+// keyLocks — блокировки по ключу (ID загрузки, имя файла кэша).
+// Запись о ключе живёт, пока блокировку кто-то держит или ждёт, поэтому карта не растёт.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock захватывает блокировку ключа key
+func (k *keyLocks) lock(key string) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+	l.Lock()
+}
+
+// unlock отпускает блокировку и удаляет её запись, если её больше никто не ждёт
+func (k *keyLocks) unlock(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l := k.locks[key]
+	l.Unlock()
+	if l.refs--; l.refs == 0 {
+		delete(k.locks, key)
+	}
+}
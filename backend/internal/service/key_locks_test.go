@@ -0,0 +1,28 @@
+package service
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestKeyLocksAreReleased(t *testing.T) {
+	var locks keyLocks
+	var wg sync.WaitGroup
+	counter := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			locks.lock(key)
+			defer locks.unlock(key)
+			counter++
+		}("same")
+	}
+	wg.Wait()
+	if counter != 50 {
+		t.Fatalf("counter = %d, want 50", counter)
+	}
+	if len(locks.locks) != 0 {
+		t.Fatalf("%d lock entries left", len(locks.locks))
+	}
+}
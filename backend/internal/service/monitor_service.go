@@ -1,10 +1,21 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TryHanger/digital_signage/backend/internal/media"
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	"github.com/TryHanger/digital_signage/backend/internal/repository"
 	"github.com/TryHanger/digital_signage/backend/internal/utils"
-	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidMonitor  = errors.New("invalid monitor")
+	ErrMonitorNotFound = errors.New("monitor not found")
 )
 
 type MonitorService struct {
@@ -20,6 +31,9 @@ func (s *MonitorService) GetAllMonitors() ([]model.Monitor, error) {
 }
 
 func (s *MonitorService) CreateMonitor(monitor *model.Monitor) error {
+	if err := validateResolution(monitor.Width, monitor.Height); err != nil {
+		return err
+	}
 	for {
 		monitor.Token = utils.GenerateShortToken()
 		err := s.repo.Create(monitor)
@@ -34,3 +48,32 @@ func (s *MonitorService) CreateMonitor(monitor *model.Monitor) error {
 	}
 	return nil
 }
+
+// SetResolution задаёт заявленное разрешение экрана; 0×0 — не задано (изображения без подгонки)
+func (s *MonitorService) SetResolution(id uint, width, height int) (*model.Monitor, error) {
+	if err := validateResolution(width, height); err != nil {
+		return nil, err
+	}
+	monitor, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMonitorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateResolution(id, width, height); err != nil {
+		return nil, err
+	}
+	monitor.Width, monitor.Height = width, height
+	return monitor, nil
+}
+
+func validateResolution(width, height int) error {
+	if width == 0 && height == 0 {
+		return nil
+	}
+	if width <= 0 || height <= 0 || width > media.MaxRenditionSide || height > media.MaxRenditionSide {
+		return fmt.Errorf("%w: resolution must be between 1 and %d on each side", ErrInvalidMonitor, media.MaxRenditionSide)
+	}
+	return nil
+}
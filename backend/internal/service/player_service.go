@@ -1,17 +1,311 @@
 package service
 
-// PlayerService отвечает за регистрацию и работу плееров (мониторов)
-//type PlayerService struct {
-//	notifier *socket.WebSocketNotifier
-//}
-//
-//// NewPlayerService создаёт сервис для управления плеерами
-//func NewPlayerService(notifier *socket.WebSocketNotifier) *PlayerService {
-//	return &PlayerService{notifier: notifier}
-//}
-//
-//// RegisterHandlers задаёт callback, который вызывается при подключении нового плеера
-//func (s *PlayerService) RegisterHandlers(handler func(monitorID string)) {
-//	//s.notifier.RegisterMonitor("register_monitor", handler)
-//	log.Println("🎧 PlayerService: обработчики зарегистрированы")
-//}
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/utils"
+)
+
+// PlayerService вычисляет, что должен показывать монитор в заданный момент
+type PlayerService struct {
+	monitorRepo  *repository.MonitorRepository
+	scheduleRepo *repository.ScheduleRepository
+	defaultRepo  *repository.DefaultPlaylistRepository
+	playlistRepo *repository.PlaylistRepository
+	renditions   *RenditionSigner
+}
+
+// NewPlayerService создаёт сервис для плееров (мониторов)
+func NewPlayerService(monitorRepo *repository.MonitorRepository, scheduleRepo *repository.ScheduleRepository, defaultRepo *repository.DefaultPlaylistRepository, playlistRepo *repository.PlaylistRepository, renditions *RenditionSigner) *PlayerService {
+	return &PlayerService{monitorRepo: monitorRepo, scheduleRepo: scheduleRepo, defaultRepo: defaultRepo, playlistRepo: playlistRepo, renditions: renditions}
+}
+
+// Источник контента в Playback
+const (
+	PlaybackSourceSchedule = "schedule"
+	PlaybackSourceDefault  = "default"
+	PlaybackSourceNone     = "none"
+)
+
+// Playback — сгенерированный цикл показа для монитора
+type Playback struct {
+	MonitorID uint `json:"monitorId"`
+
+	// Откуда взят контент: расписание, плейлист по умолчанию или ничего
+	Source            string             `json:"source"`
+	DefaultLevel      model.DefaultLevel `json:"defaultLevel,omitempty"`
+	DefaultPlaylistID uint               `json:"defaultPlaylistId,omitempty"`
+
+	ScheduleID   uint               `json:"scheduleId,omitempty"`
+	BlockID      uint               `json:"blockId,omitempty"`
+	BlockName    string             `json:"blockName,omitempty"`
+	StartTime    string             `json:"startTime,omitempty"`
+	EndTime      string             `json:"endTime,omitempty"`
+	RotationMode model.RotationMode `json:"rotationMode,omitempty"`
+	LoopDuration int                `json:"loopDuration"`
+	Items        []PlaybackItem     `json:"items"`
+
+	// Раскладка шаблона: Items занимают весь холст, области со своими циклами — поверх него
+	CanvasWidth  int            `json:"canvasWidth,omitempty"`
+	CanvasHeight int            `json:"canvasHeight,omitempty"`
+	Zones        []PlaybackZone `json:"zones,omitempty"`
+
+	// Revision меняется при любом изменении цикла, в том числе при замене файла
+	// или откате контента: плеер перезагружает манифест и файлы, только если она сменилась
+	Revision    string    `json:"revision"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+type PlaybackItem struct {
+	ContentID uint   `json:"contentId"`
+	Title     string `json:"title"`
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	Version   int    `json:"version,omitempty"` // версия файла контента
+	Body      string `json:"body,omitempty"`
+	Duration  int    `json:"duration"`
+	// FeedURL — закэшированные записи ленты на сервере (для вида feed)
+	FeedURL string `json:"feedUrl,omitempty"`
+
+	// Для веб-страниц
+	RefreshInterval int     `json:"refreshInterval,omitempty"`
+	Zoom            float64 `json:"zoom,omitempty"`
+}
+
+// PlaybackZone — область экрана с собственным последовательным циклом.
+// Координаты — в пикселях холста шаблона, ZIndex — порядок наложения.
+type PlaybackZone struct {
+	Name         string         `json:"name"`
+	X            int            `json:"x"`
+	Y            int            `json:"y"`
+	Width        int            `json:"width"`
+	Height       int            `json:"height"`
+	ZIndex       int            `json:"zIndex"`
+	LoopDuration int            `json:"loopDuration"`
+	Items        []PlaybackItem `json:"items"`
+}
+
+// GetPlayback возвращает цикл показа для монитора с токеном token на момент at
+func (s *PlayerService) GetPlayback(token string, at time.Time) (*Playback, error) {
+	monitor, err := s.monitorRepo.GetByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.scheduleRepo.GetForMonitor(monitor)
+	if err != nil {
+		return nil, err
+	}
+
+	playback := &Playback{MonitorID: monitor.ID, Source: PlaybackSourceNone, Items: []PlaybackItem{}, GeneratedAt: at}
+
+	schedule, block := pickBlock(monitor, schedules, at)
+	if block != nil {
+		// После полуночи ночной блок относится ко дню своего начала — окна элементов считаем от него
+		day := utils.BlockStartDay(*block, at)
+		items := playableItems(block.Items, day, at)
+		loop := buildLoop(block.RotationMode, zoneItems(items, ""))
+		zonePlaylists, err := s.zonePlaylists(schedule.Template)
+		if err != nil {
+			return nil, err
+		}
+		zones := s.zoneLoops(schedule.Template, items, zonePlaylists, day, at, monitor)
+		if len(loop) > 0 || len(zones) > 0 {
+			if len(zones) > 0 {
+				playback.CanvasWidth = schedule.Template.CanvasWidth
+				playback.CanvasHeight = schedule.Template.CanvasHeight
+				playback.Zones = zones
+			}
+			playback.Source = PlaybackSourceSchedule
+			playback.ScheduleID = schedule.ID
+			playback.BlockID = block.ID
+			playback.BlockName = block.Name
+			playback.StartTime = block.StartTime
+			playback.EndTime = block.EndTime
+			playback.RotationMode = block.RotationMode
+			s.fill(playback, loop, monitor)
+			playback.Revision = playback.revision()
+			return playback, nil
+		}
+	}
+
+	// Расписания не покрывают текущий момент — берём самый специфичный плейлист по умолчанию
+	defaults, err := s.defaultRepo.GetCandidatesForMonitor(monitor)
+	if err != nil {
+		return nil, err
+	}
+	s.fillDefault(playback, defaults, at, monitor)
+	playback.Revision = playback.revision()
+	return playback, nil
+}
+
+// fillDefault заполняет цикл самым специфичным из плейлистов по умолчанию, если такой есть
+func (s *PlayerService) fillDefault(p *Playback, defaults []model.DefaultPlaylist, at time.Time, monitor *model.Monitor) {
+	fallback := mostSpecificDefault(defaults)
+	if fallback == nil {
+		return
+	}
+	p.Source = PlaybackSourceDefault
+	p.DefaultLevel = fallback.Level
+	p.DefaultPlaylistID = fallback.ID
+	s.fill(p, buildLoop(model.RotationSequential, playableItems(defaultItems(fallback), at, at)), monitor)
+}
+
+// fill заполняет элементы основного цикла; изображения отдаются подогнанными под разрешение монитора
+func (s *PlayerService) fill(p *Playback, loop []model.ScheduleBlockItem, monitor *model.Monitor) {
+	items, duration := s.playbackItems(loop, monitor.Width, monitor.Height)
+	p.Items = append(p.Items, items...)
+	p.LoopDuration += duration
+}
+
+// zoneLoops строит циклы областей раскладки шаблона; области без элементов не попадают в ответ.
+// Область, для которой у блока нет элементов, играет свой плейлист, если он задан.
+// Изображения подгоняются под размер области на экране монитора.
+func (s *PlayerService) zoneLoops(template *model.Template, items []model.ScheduleBlockItem, playlists map[uint]model.Playlist, day, at time.Time, monitor *model.Monitor) []PlaybackZone {
+	if template == nil || template.CanvasWidth <= 0 || template.CanvasHeight <= 0 {
+		return nil
+	}
+	var zones []PlaybackZone
+	for _, z := range template.Zones {
+		own := zoneItems(items, z.Name)
+		if len(own) == 0 && z.PlaylistID != nil {
+			if playlist, ok := playlists[*z.PlaylistID]; ok {
+				own = playableItems(repository.ZonePlaylistItems(&playlist, z.Name), day, at)
+			}
+		}
+		loop := buildLoop(model.RotationSequential, own)
+		if len(loop) == 0 {
+			continue
+		}
+		zone := PlaybackZone{Name: z.Name, X: z.X, Y: z.Y, Width: z.Width, Height: z.Height, ZIndex: z.ZIndex}
+		width := z.Width * monitor.Width / template.CanvasWidth
+		height := z.Height * monitor.Height / template.CanvasHeight
+		zone.Items, zone.LoopDuration = s.playbackItems(loop, width, height)
+		zones = append(zones, zone)
+	}
+	sort.SliceStable(zones, func(i, j int) bool { return zones[i].ZIndex < zones[j].ZIndex })
+	return zones
+}
+
+// zonePlaylists загружает собственные плейлисты областей шаблона
+func (s *PlayerService) zonePlaylists(template *model.Template) (map[uint]model.Playlist, error) {
+	var ids []uint
+	if template != nil {
+		for _, z := range template.Zones {
+			if z.PlaylistID != nil {
+				ids = append(ids, *z.PlaylistID)
+			}
+		}
+	}
+	return s.playlistRepo.GetByIDs(ids)
+}
+
+// zoneItems отбирает элементы области zone; пусто — основная область
+func zoneItems(items []model.ScheduleBlockItem, zone string) []model.ScheduleBlockItem {
+	var result []model.ScheduleBlockItem
+	for _, item := range items {
+		if item.Zone == zone {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// playbackItems превращает цикл в элементы Playback; изображения подгоняются под width×height
+func (s *PlayerService) playbackItems(loop []model.ScheduleBlockItem, width, height int) ([]PlaybackItem, int) {
+	items := make([]PlaybackItem, 0, len(loop))
+	total := 0
+	for _, item := range loop {
+		pi := PlaybackItem{ContentID: item.ContentID, Duration: itemDuration(item)}
+		if item.Content != nil {
+			pi.Title = item.Content.Title
+			pi.Type = item.Content.Type
+			pi.Path = item.Content.Path
+			if path := s.renditions.Path(item.Content, width, height); path != "" {
+				pi.Path = path
+			}
+			pi.Version = item.Content.Version
+			pi.Body = item.Content.Body
+			pi.RefreshInterval = item.Content.RefreshInterval
+			pi.Zoom = item.Content.Zoom
+			if item.Content.Type == model.ContentTypeFeed {
+				pi.FeedURL = fmt.Sprintf("/api/v1/contents/%d/feed", item.ContentID)
+			}
+		}
+		total += pi.Duration
+		items = append(items, pi)
+	}
+	return items, total
+}
+
+// revision — хэш содержимого Playback без момента генерации
+func (p *Playback) revision() string {
+	snapshot := *p
+	snapshot.Revision, snapshot.GeneratedAt = "", time.Time{}
+	data, _ := json.Marshal(snapshot)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// defaultItems приводит элементы плейлиста по умолчанию к элементам блока
+func defaultItems(playlist *model.DefaultPlaylist) []model.ScheduleBlockItem {
+	items := make([]model.ScheduleBlockItem, 0, len(playlist.Items))
+	for _, it := range playlist.Items {
+		items = append(items, model.ScheduleBlockItem{
+			ContentID: it.ContentID,
+			Content:   it.Content,
+			Position:  it.Position,
+			Duration:  it.Duration,
+		})
+	}
+	return items
+}
+
+// pickBlock выбирает блок, который должен играть в момент at.
+// Приоритет: расписание монитора > группы > локации, затем более позднее по дате начала.
+func pickBlock(monitor *model.Monitor, schedules []model.Schedule, at time.Time) (*model.Schedule, *model.ScheduleBlock) {
+	var bestSchedule *model.Schedule
+	var bestBlock *model.ScheduleBlock
+	bestRank := -1
+
+	for i := range schedules {
+		sched := &schedules[i]
+		rank := scheduleRank(monitor, sched)
+		if rank < bestRank {
+			continue
+		}
+		for j := range sched.Blocks {
+			block := &sched.Blocks[j]
+			if !utils.IsBlockActiveAt(*block, at) {
+				continue
+			}
+			// часть ночного блока после полуночи относится к дню, в который блок начался
+			if !utils.IsActiveOn(*sched, utils.BlockStartDay(*block, at)) {
+				continue
+			}
+			if rank > bestRank || bestSchedule == nil || sched.StartDate.After(bestSchedule.StartDate) {
+				bestSchedule, bestBlock, bestRank = sched, block, rank
+			}
+			break
+		}
+	}
+	return bestSchedule, bestBlock
+}
+
+func scheduleRank(monitor *model.Monitor, schedule *model.Schedule) int {
+	for _, m := range schedule.Monitors {
+		if m.ID == monitor.ID {
+			return 3
+		}
+	}
+	if schedule.GroupID != nil && monitor.GroupID != nil && *schedule.GroupID == *monitor.GroupID {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/lib/pq"
+)
+
+func TestPickBlockOvernight(t *testing.T) {
+	// расписание только по пятницам; 2026-10-16 — пятница
+	schedules := []model.Schedule{{
+		ID:         1,
+		IsActive:   true,
+		StartDate:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		RepeatType: model.RepeatWeekly,
+		Weekdays:   pq.Int64Array{model.Friday},
+		Blocks:     []model.ScheduleBlock{{ID: 10, StartTime: "22:00", EndTime: "06:00"}},
+	}}
+	monitor := &model.Monitor{ID: 1}
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"friday 23:00", time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC), true},
+		{"saturday 02:00 continues friday", time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC), true},
+		{"friday 02:00 belongs to thursday", time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block := pickBlock(monitor, schedules, tt.at)
+			if (block != nil) != tt.want {
+				t.Fatalf("block = %v, want active %v", block, tt.want)
+			}
+		})
+	}
+}
+
+func TestZoneLoopsFallBackToZonePlaylist(t *testing.T) {
+	approved := func(id uint) *model.Content {
+		return &model.Content{ID: id, Type: model.ContentTypeText, Duration: 10, ReviewStatus: model.ReviewApproved}
+	}
+	promoPlaylist, tickerPlaylist := uint(5), uint(6)
+	template := &model.Template{
+		CanvasWidth: 1920, CanvasHeight: 1080,
+		Zones: []model.TemplateZone{
+			{Name: "promo", Width: 480, Height: 1080, PlaylistID: &promoPlaylist},
+			{Name: "ticker", Y: 980, Width: 1920, Height: 100, ZIndex: 1, PlaylistID: &tickerPlaylist},
+		},
+	}
+	playlists := map[uint]model.Playlist{
+		promoPlaylist: {ID: promoPlaylist, Items: []model.PlaylistItem{{ContentID: 1, Content: approved(1), Position: 1}}},
+		tickerPlaylist: {ID: tickerPlaylist, Items: []model.PlaylistItem{
+			{ContentID: 2, Content: approved(2), Position: 1, Zone: "elsewhere"},
+			{ContentID: 3, Content: approved(3), Position: 2, StartTime: "20:00", EndTime: "21:00"},
+		}},
+	}
+	// у блока есть свой элемент для promo: плейлист области promo не нужен
+	items := []model.ScheduleBlockItem{{ContentID: 4, Content: approved(4), Zone: "promo"}}
+	s := &PlayerService{renditions: NewRenditionSigner("secret")}
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	zones := s.zoneLoops(template, items, playlists, at, at, &model.Monitor{Width: 1920, Height: 1080})
+	if len(zones) != 2 {
+		t.Fatalf("zones = %+v", zones)
+	}
+	if got := zones[0].Items; zones[0].Name != "promo" || len(got) != 1 || got[0].ContentID != 4 {
+		t.Fatalf("promo = %+v", zones[0])
+	}
+	// элемент 3 вне своего окна действия
+	if got := zones[1].Items; zones[1].Name != "ticker" || len(got) != 1 || got[0].ContentID != 2 {
+		t.Fatalf("ticker = %+v", zones[1])
+	}
+}
+
+func TestMostSpecificDefault(t *testing.T) {
+	global := model.DefaultPlaylist{ID: 1, Level: model.DefaultLevelGlobal}
+	location := model.DefaultPlaylist{ID: 2, Level: model.DefaultLevelLocation}
+	group := model.DefaultPlaylist{ID: 3, Level: model.DefaultLevelGroup}
+	monitor := model.DefaultPlaylist{ID: 4, Level: model.DefaultLevelMonitor}
+	tests := []struct {
+		name      string
+		playlists []model.DefaultPlaylist
+		want      uint
+	}{
+		{"none", nil, 0},
+		{"only global", []model.DefaultPlaylist{global}, 1},
+		{"location over global", []model.DefaultPlaylist{global, location}, 2},
+		{"group over location", []model.DefaultPlaylist{location, group, global}, 3},
+		{"monitor over everything", []model.DefaultPlaylist{group, monitor, location, global}, 4},
+		{"monitor listed first", []model.DefaultPlaylist{monitor, global, group}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uint
+			if best := mostSpecificDefault(tt.playlists); best != nil {
+				got = best.ID
+			}
+			if got != tt.want {
+				t.Fatalf("mostSpecificDefault = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillDefault(t *testing.T) {
+	content := func(id uint, status string) *model.Content {
+		return &model.Content{ID: id, Type: model.ContentTypeText, Duration: 10, ReviewStatus: status}
+	}
+	defaults := []model.DefaultPlaylist{
+		{ID: 1, Level: model.DefaultLevelGlobal, Items: []model.DefaultPlaylistItem{
+			{ContentID: 1, Content: content(1, model.ReviewApproved), Position: 1},
+		}},
+		{ID: 2, Level: model.DefaultLevelGroup, Items: []model.DefaultPlaylistItem{
+			{ContentID: 2, Content: content(2, model.ReviewApproved), Position: 2},
+			{ContentID: 3, Content: content(3, model.ReviewPending), Position: 1},
+		}},
+	}
+	s := &PlayerService{renditions: NewRenditionSigner("secret")}
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	monitor := &model.Monitor{Width: 1920, Height: 1080}
+	tests := []struct {
+		name      string
+		defaults  []model.DefaultPlaylist
+		source    string
+		playlist  uint
+		level     model.DefaultLevel
+		contentID []uint
+	}{
+		{"no defaults", nil, PlaybackSourceNone, 0, "", nil},
+		{"group playlist wins, unapproved skipped", defaults, PlaybackSourceDefault, 2, model.DefaultLevelGroup, []uint{2}},
+		{"global when nothing more specific", defaults[:1], PlaybackSourceDefault, 1, model.DefaultLevelGlobal, []uint{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Playback{Source: PlaybackSourceNone, Items: []PlaybackItem{}}
+			s.fillDefault(p, tt.defaults, at, monitor)
+			if p.Source != tt.source || p.DefaultPlaylistID != tt.playlist || p.DefaultLevel != tt.level {
+				t.Fatalf("playback = %+v", p)
+			}
+			if len(p.Items) != len(tt.contentID) {
+				t.Fatalf("items = %+v, want %v", p.Items, tt.contentID)
+			}
+			for i, id := range tt.contentID {
+				if p.Items[i].ContentID != id {
+					t.Fatalf("items = %+v, want %v", p.Items, tt.contentID)
+				}
+			}
+		})
+	}
+}
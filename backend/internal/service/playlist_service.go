@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPlaylist  = errors.New("invalid playlist")
+	ErrPlaylistNotFound = errors.New("playlist not found")
+	ErrPlaylistInUse    = errors.New("playlist is linked from templates or schedules")
+)
+
+// PlaylistService управляет именованными плейлистами. Блок шаблона или расписания
+// ссылается на плейлист либо живой ссылкой (изменения плейлиста сразу видны в блоке),
+// либо снимком (элементы копируются в блок при сохранении).
+type PlaylistService struct {
+	repo        *repository.PlaylistRepository
+	contentRepo *repository.ContentRepository
+	schedules   *ScheduleService
+	templates   *TemplateService
+}
+
+func NewPlaylistService(repo *repository.PlaylistRepository, contentRepo *repository.ContentRepository, schedules *ScheduleService, templates *TemplateService) *PlaylistService {
+	return &PlaylistService{repo: repo, contentRepo: contentRepo, schedules: schedules, templates: templates}
+}
+
+func (s *PlaylistService) Create(playlist *model.Playlist) error {
+	if err := s.validate(playlist); err != nil {
+		return err
+	}
+	return s.repo.Create(playlist)
+}
+
+func (s *PlaylistService) GetAll() ([]model.Playlist, error) {
+	return s.repo.GetAll()
+}
+
+func (s *PlaylistService) GetByID(id uint) (*model.Playlist, error) {
+	playlist, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlaylistNotFound
+	}
+	return playlist, err
+}
+
+func (s *PlaylistService) Update(playlist *model.Playlist) error {
+	existing, err := s.GetByID(playlist.ID)
+	if err != nil {
+		return err
+	}
+	playlist.CreatedAt = existing.CreatedAt
+	if err := s.validate(playlist); err != nil {
+		return err
+	}
+	if err := s.checkLinkedBlocks(playlist); err != nil {
+		return err
+	}
+	return s.repo.Update(playlist)
+}
+
+// checkLinkedBlocks проверяет шаблоны и расписания, блоки которых ссылаются на плейлист:
+// после изменения они должны остаться корректными (области, ротация, окна действия)
+func (s *PlaylistService) checkLinkedBlocks(playlist *model.Playlist) error {
+	usages, err := s.repo.Usages(playlist.ID)
+	if err != nil {
+		return err
+	}
+	checked := make(map[string]bool)
+	for _, u := range usages {
+		key := fmt.Sprintf("%s:%d", u.Kind, u.ID)
+		if u.Mode != model.PlaylistLink || checked[key] {
+			continue
+		}
+		checked[key] = true
+		switch u.Kind {
+		case "template":
+			template, err := s.templates.GetByID(u.ID)
+			if err != nil {
+				return err
+			}
+			if err := s.templates.checkPlaylist(template, playlist); err != nil {
+				return fmt.Errorf("%w: template %q: %v", ErrInvalidPlaylist, template.Name, err)
+			}
+		case "schedule":
+			schedule, err := s.schedules.GetByID(u.ID)
+			if err != nil {
+				return err
+			}
+			if err := s.schedules.checkPlaylist(schedule, playlist); err != nil {
+				return fmt.Errorf("%w: schedule %q: %v", ErrInvalidPlaylist, schedule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Usages возвращает блоки шаблонов и расписаний и области шаблонов, ссылающиеся на плейлист
+func (s *PlaylistService) Usages(id uint) ([]repository.PlaylistUsage, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.Usages(id)
+}
+
+// Delete удаляет плейлист. Пока на него есть живые ссылки, удаление запрещено:
+// блоки остались бы без контента. Снимки сохраняют свои элементы.
+func (s *PlaylistService) Delete(id uint) error {
+	usages, err := s.Usages(id)
+	if err != nil {
+		return err
+	}
+	for _, u := range usages {
+		if u.Mode == model.PlaylistLink {
+			return ErrPlaylistInUse
+		}
+	}
+	return s.repo.Delete(id)
+}
+
+// validate проверяет название и элементы: контент должен существовать,
+// у элементов без длительности её должен задавать сам контент,
+// а параметры ротации и окно действия — быть допустимыми
+func (s *PlaylistService) validate(playlist *model.Playlist) error {
+	playlist.Name = strings.TrimSpace(playlist.Name)
+	if playlist.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPlaylist)
+	}
+	ids := make([]uint, 0, len(playlist.Items))
+	for _, item := range playlist.Items {
+		ids = append(ids, item.ContentID)
+	}
+	contents, err := s.contentRepo.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	for i := range playlist.Items {
+		item := &playlist.Items[i]
+		item.Content = nil // контент только по ContentID
+		item.Position = i + 1
+		if item.Duration != nil && *item.Duration < 0 {
+			return fmt.Errorf("%w: item %d: duration must not be negative", ErrInvalidPlaylist, i+1)
+		}
+		if item.Weight < 0 || item.PlaysPerHour < 0 {
+			return fmt.Errorf("%w: item %d: weight and playsPerHour must not be negative", ErrInvalidPlaylist, i+1)
+		}
+		if err := checkItemWindow(item.ValidFrom, item.ValidUntil, item.Weekdays, item.StartTime, item.EndTime); err != nil {
+			return fmt.Errorf("%w: item %d: %v", ErrInvalidPlaylist, i+1, err)
+		}
+		if item.Duration != nil && *item.Duration > 0 {
+			if _, ok := contents[item.ContentID]; !ok {
+				return fmt.Errorf("%w: item %d: content %d not found", ErrInvalidPlaylist, i+1, item.ContentID)
+			}
+			continue
+		}
+		if err := checkContentDuration(contents, item.ContentID); err != nil {
+			return fmt.Errorf("%w: item %d: %v", ErrInvalidPlaylist, i+1, err)
+		}
+	}
+	return nil
+}
+
+// playlistsFor загружает плейлисты, на которые ссылаются блоки
+func playlistsFor(repo *repository.PlaylistRepository, ids []uint) (map[uint]model.Playlist, error) {
+	playlists, err := repo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, ok := playlists[id]; !ok {
+			return nil, fmt.Errorf("playlist %d not found", id)
+		}
+	}
+	return playlists, nil
+}
+
+// checkPlaylistMode проверяет режим ссылки блока на плейлист; без режима — живая ссылка
+func checkPlaylistMode(blockName string, playlistID *uint, mode *model.PlaylistMode) error {
+	if playlistID == nil {
+		if *mode != "" {
+			return fmt.Errorf("block %q: playlistMode requires playlistId", blockName)
+		}
+		return nil
+	}
+	switch *mode {
+	case "":
+		*mode = model.PlaylistLink
+	case model.PlaylistLink, model.PlaylistSnapshot:
+	default:
+		return fmt.Errorf("block %q: unknown playlist mode %q", blockName, *mode)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+)
+
+func TestCheckItemWindow(t *testing.T) {
+	from := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 0, -1)
+	tests := []struct {
+		name    string
+		item    model.PlaylistItem
+		wantErr string
+	}{
+		{"no window", model.PlaylistItem{}, ""},
+		{"full window", model.PlaylistItem{ValidFrom: &from, Weekdays: []int64{1, 7}, StartTime: "09:00", EndTime: "18:30"}, ""},
+		{"reversed dates", model.PlaylistItem{ValidFrom: &from, ValidUntil: &until}, "validUntil is before validFrom"},
+		{"bad weekday", model.PlaylistItem{Weekdays: []int64{0}}, "invalid weekday 0"},
+		{"bad clock", model.PlaylistItem{StartTime: "25:00"}, "invalid time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := tt.item
+			err := checkItemWindow(it.ValidFrom, it.ValidUntil, it.Weekdays, it.StartTime, it.EndTime)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckTemplatePlaylist(t *testing.T) {
+	playlist := &model.Playlist{Name: "Акции", Items: []model.PlaylistItem{{ContentID: 1, Zone: "promo", Weight: 2}}}
+	if err := checkTemplatePlaylist("Утро", playlist); err != nil {
+		t.Fatalf("playlist without windows: %v", err)
+	}
+	playlist.Items = append(playlist.Items, model.PlaylistItem{ContentID: 2, StartTime: "10:00"})
+	if err := checkTemplatePlaylist("Утро", playlist); err == nil || !strings.Contains(err.Error(), "item 2") {
+		t.Fatalf("err = %v, want rejection of item 2", err)
+	}
+}
+
+func TestPlaylistZonesAreCheckedAgainstTemplateLayout(t *testing.T) {
+	playlist := &model.Playlist{Items: []model.PlaylistItem{{ContentID: 1, Zone: "ticker"}}}
+	template := &model.Template{
+		Zones:  []model.TemplateZone{{Name: "promo", Width: 100, Height: 100}},
+		Blocks: []model.TemplateBlock{{Name: "Утро"}},
+	}
+	template.Blocks[0].Contents = repository.PlaylistTemplateContents(playlist, 0)
+	if err := validateLayout(template); err == nil || !strings.Contains(err.Error(), `unknown zone "ticker"`) {
+		t.Fatalf("err = %v, want unknown zone", err)
+	}
+}
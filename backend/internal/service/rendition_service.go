@@ -0,0 +1,251 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/media"
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// ErrRenditionSignature — ссылка на рендишен не подписана сервером
+var ErrRenditionSignature = errors.New("invalid rendition signature")
+
+// RenditionSigner подписывает ссылки на рендишены. Размер задаёт клиент, поэтому
+// без подписи любой мог бы заставить сервер строить и хранить рендишены произвольного размера.
+type RenditionSigner struct {
+	secret []byte
+}
+
+// NewRenditionSigner создаёт подписчик с ключом secret; без ключа берётся случайный,
+// и ссылки перестают действовать после перезапуска (плееры получат новые в Playback)
+func NewRenditionSigner(secret string) *RenditionSigner {
+	if secret != "" {
+		return &RenditionSigner{secret: []byte(secret)}
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	log.Printf("⚠️ PRESIGN_SECRET не задан: ссылки на рендишены подписываются случайным ключом")
+	return &RenditionSigner{secret: key}
+}
+
+// Path — ссылка на изображение контента, подогнанное под область экрана width×height;
+// пусто, если подгонка не нужна (не изображение или разрешение монитора не задано)
+func (s *RenditionSigner) Path(content *model.Content, width, height int) string {
+	if content.Type != model.ContentTypeImage || content.Checksum == "" || width <= 0 || height <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("/api/v1/contents/%d/rendition?w=%d&h=%d&v=%d&sig=%s",
+		content.ID, width, height, content.Version, s.sign(content.ID, width, height, ""))
+}
+
+// Verify проверяет подпись параметров рендишена
+func (s *RenditionSigner) Verify(id uint, width, height int, mode, signature string) bool {
+	return hmac.Equal([]byte(s.sign(id, width, height, mode)), []byte(signature))
+}
+
+func (s *RenditionSigner) sign(id uint, width, height int, mode string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%d\n%d\n%d\n%s", id, width, height, mode)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RenditionService подгоняет изображения под разрешение монитора и кэширует
+// результат на диске. Рендишен зависит только от файла и параметров подгонки,
+// поэтому один файл кэша обслуживает все мониторы с тем же разрешением.
+type RenditionService struct {
+	repo   *repository.ContentRepository
+	store  storage.Storage
+	signer *RenditionSigner
+	dir    string
+	ttl    time.Duration
+	locks  keyLocks
+}
+
+func NewRenditionService(repo *repository.ContentRepository, store storage.Storage, signer *RenditionSigner, dir string, ttl time.Duration) (*RenditionService, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &RenditionService{repo: repo, store: store, signer: signer, dir: dir, ttl: ttl}, nil
+}
+
+// Rendition — подогнанное изображение в кэше
+type Rendition struct {
+	ETag    string
+	ModTime time.Time
+	File    *os.File
+}
+
+// Open возвращает изображение контента id, подогнанное под экран width×height.
+// mode переопределяет режим подгонки контента; пусто — режим контента.
+// signature — подпись параметров, выданная RenditionSigner.Path.
+func (s *RenditionService) Open(id uint, width, height int, mode, signature string) (*Rendition, error) {
+	if !s.signer.Verify(id, width, height, mode, signature) {
+		return nil, ErrRenditionSignature
+	}
+	content, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrContentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if content.Type != model.ContentTypeImage || content.Checksum == "" {
+		return nil, fmt.Errorf("%w: content %d is not an uploaded image", ErrInvalidContent, id)
+	}
+	opts := renditionOptions(content, width, height, mode)
+	if opts.Width <= 0 || opts.Height <= 0 || opts.Width > media.MaxRenditionSide || opts.Height > media.MaxRenditionSide {
+		return nil, fmt.Errorf("%w: rendition size must be between 1 and %d", ErrInvalidContent, media.MaxRenditionSide)
+	}
+	if !media.ValidRenditionMode(opts.Mode) {
+		return nil, fmt.Errorf("%w: unknown fit mode %q", ErrInvalidContent, opts.Mode)
+	}
+
+	name := renditionName(content.Checksum, opts)
+	path := filepath.Join(s.dir, content.Checksum[:2], name)
+	s.locks.lock(name)
+	defer s.locks.unlock(name)
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := s.render(content, path, opts); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	// время изменения — время последнего обращения: по нему чистится кэш
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Rendition{ETag: `"` + name + `"`, ModTime: content.UpdatedAt, File: f}, nil
+}
+
+// render строит рендишен из оригинала в хранилище и атомарно кладёт его в кэш.
+// Оригинал копируется во временный файл не длиннее размера, записанного у контента.
+func (s *RenditionService) render(content *model.Content, path string, opts media.RenditionOptions) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	src, err := os.CreateTemp(filepath.Dir(path), ".source-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(src.Name())
+	defer src.Close()
+
+	r, err := s.store.Get(mediaKey(content.Checksum))
+	if err != nil {
+		return err
+	}
+	size, err := io.Copy(src, io.LimitReader(r, content.Size+1))
+	r.Close()
+	if err != nil {
+		return err
+	}
+	if size != content.Size {
+		return fmt.Errorf("stored file of content %d has %d bytes, expected %d", content.ID, size, content.Size)
+	}
+	out, err := media.Rendition(src, size, opts)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".render-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// StartCleanup периодически удаляет рендишены, к которым не обращались дольше TTL
+func (s *RenditionService) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.CleanupExpired()
+		}
+	}()
+}
+
+func (s *RenditionService) CleanupExpired() {
+	deadline := time.Now().Add(-s.ttl)
+	removed := 0
+	err := filepath.WalkDir(s.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if s.removeExpired(path, d.Name(), deadline) {
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Ошибка очистки кэша рендишенов: %v", err)
+	}
+	if removed > 0 {
+		log.Printf("🧹 Удалено неиспользуемых рендишенов: %d", removed)
+	}
+}
+
+// removeExpired удаляет файл кэша, если к нему не обращались с deadline. Время обращения
+// проверяется под блокировкой файла: Open мог только что продлить его или ещё строит рендишен.
+func (s *RenditionService) removeExpired(path, name string, deadline time.Time) bool {
+	s.locks.lock(name)
+	defer s.locks.unlock(name)
+	info, err := os.Stat(path)
+	if err != nil || !info.ModTime().Before(deadline) {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+func renditionOptions(content *model.Content, width, height int, mode string) media.RenditionOptions {
+	opts := media.RenditionOptions{Width: width, Height: height, Mode: mode, FocusX: 0.5, FocusY: 0.5}
+	if opts.Mode == "" {
+		opts.Mode = content.FitMode
+	}
+	if opts.Mode == "" {
+		opts.Mode = media.RenditionFit
+	}
+	if content.FocusX != nil {
+		opts.FocusX = *content.FocusX
+	}
+	if content.FocusY != nil {
+		opts.FocusY = *content.FocusY
+	}
+	return opts
+}
+
+// renditionName — имя файла кэша: файл-источник и все параметры подгонки
+func renditionName(checksum string, opts media.RenditionOptions) string {
+	name := fmt.Sprintf("%s-%dx%d-%s", checksum, opts.Width, opts.Height, opts.Mode)
+	if opts.Mode == media.RenditionCrop {
+		name += "-" + strconv.FormatFloat(opts.FocusX, 'f', 3, 64) + "-" + strconv.FormatFloat(opts.FocusY, 'f', 3, 64)
+	}
+	return name + ".jpg"
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+func TestRenditionSignerPath(t *testing.T) {
+	signer := NewRenditionSigner("secret")
+	content := &model.Content{ID: 7, Type: model.ContentTypeImage, Checksum: "abcdef", Version: 3}
+
+	u, err := url.Parse(signer.Path(content, 1920, 1080))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/api/v1/contents/7/rendition" {
+		t.Fatalf("path = %s", u.Path)
+	}
+	q := u.Query()
+	w, _ := strconv.Atoi(q.Get("w"))
+	h, _ := strconv.Atoi(q.Get("h"))
+	if !signer.Verify(7, w, h, "", q.Get("sig")) {
+		t.Fatal("signature does not verify")
+	}
+	for _, c := range []struct {
+		id   uint
+		w, h int
+		mode string
+	}{{8, 1920, 1080, ""}, {7, 7680, 1080, ""}, {7, 1920, 4320, ""}, {7, 1920, 1080, "crop"}} {
+		if signer.Verify(c.id, c.w, c.h, c.mode, q.Get("sig")) {
+			t.Fatalf("signature verifies for %+v", c)
+		}
+	}
+	if NewRenditionSigner("other").Verify(7, w, h, "", q.Get("sig")) {
+		t.Fatal("signature verifies with another secret")
+	}
+
+	if path := signer.Path(&model.Content{ID: 7, Type: model.ContentTypeVideo, Checksum: "abcdef"}, 1920, 1080); path != "" {
+		t.Fatalf("video path = %q", path)
+	}
+	if path := signer.Path(content, 0, 0); path != "" {
+		t.Fatalf("path without resolution = %q", path)
+	}
+}
+
+func TestRenditionCleanupExpired(t *testing.T) {
+	dir := t.TempDir()
+	s := &RenditionService{dir: dir, ttl: time.Hour}
+	old, fresh := filepath.Join(dir, "ab", "old.jpg"), filepath.Join(dir, "ab", "fresh.jpg")
+	if err := os.MkdirAll(filepath.Dir(old), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{old, fresh} {
+		if err := os.WriteFile(path, []byte("jpeg"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(old, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	s.CleanupExpired()
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatalf("expired rendition kept: %v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("fresh rendition removed: %v", err)
+	}
+	if len(s.locks.locks) != 0 {
+		t.Fatalf("%d lock entries left", len(s.locks.locks))
+	}
+}
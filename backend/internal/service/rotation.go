@@ -0,0 +1,270 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/utils"
+)
+
+const (
+	// defaultItemDuration используется, если у элемента и у контента не задана длительность
+	defaultItemDuration = 10
+	// rotationWindow — длина генерируемого цикла для weighted/frequency (секунды)
+	rotationWindow = 3600
+)
+
+// rotationSlot — элемент блока с рассчитанным количеством показов за цикл
+type rotationSlot struct {
+	item     model.ScheduleBlockItem
+	duration int
+	plays    int
+}
+
+// itemDuration возвращает длительность показа элемента в секундах
+func itemDuration(item model.ScheduleBlockItem) int {
+	if item.Duration != nil && *item.Duration > 0 {
+		return *item.Duration
+	}
+	if item.Content != nil {
+		if d := contentDuration(item.Content); d > 0 {
+			return d
+		}
+	}
+	return defaultItemDuration
+}
+
+// checkContentDuration проверяет, что у контента без явной длительности её можно определить:
+// вручную, из файла или по умолчанию для его вида (у видео без метаданных и трансляций её нет)
+func checkContentDuration(contents map[uint]model.Content, id uint) error {
+	content, ok := contents[id]
+	if !ok {
+		return fmt.Errorf("content %d not found", id)
+	}
+	if contentDuration(&content) == 0 {
+		return fmt.Errorf("%s %q has no duration", content.Type, content.Title)
+	}
+	return nil
+}
+
+// validateRotation проверяет параметры ротации блока
+func validateRotation(block model.ScheduleBlock) error {
+	switch block.RotationMode {
+	case "", model.RotationSequential, model.RotationWeighted, model.RotationFrequency:
+	default:
+		return fmt.Errorf("block %q: unknown rotation mode %q", block.Name, block.RotationMode)
+	}
+
+	share, airtime := 0, 0
+	for _, item := range block.Items {
+		if item.Weight < 0 || item.PlaysPerHour < 0 {
+			return fmt.Errorf("block %q: weight and playsPerHour must not be negative", block.Name)
+		}
+		if block.RotationMode != model.RotationFrequency {
+			continue
+		}
+		// как и в frequencySlots, PlaysPerHour главнее Weight: элемент учитывается один раз
+		if item.PlaysPerHour > 0 {
+			airtime += item.PlaysPerHour * itemDuration(item)
+		} else {
+			share += item.Weight
+		}
+	}
+	if block.RotationMode == model.RotationFrequency {
+		if share > 100 {
+			return fmt.Errorf("block %q: airtime shares add up to %d%%", block.Name, share)
+		}
+		if airtime+share*rotationWindow/100 > rotationWindow {
+			return fmt.Errorf("block %q: frequency and share rules do not fit into an hour", block.Name)
+		}
+		// элементы без правил делят остаток часа; если его нет, они не попали бы в эфир
+		for _, slot := range frequencySlots(block.Items) {
+			if slot.plays == 0 {
+				return fmt.Errorf("block %q: content %d without frequency or share gets no airtime: the rules fill the whole hour",
+					block.Name, slot.item.ContentID)
+			}
+		}
+	}
+	return nil
+}
+
+// validateItemWindow проверяет окно действия элемента
+func validateItemWindow(block model.ScheduleBlock, item model.ScheduleBlockItem) error {
+	if err := checkItemWindow(item.ValidFrom, item.ValidUntil, item.Weekdays, item.StartTime, item.EndTime); err != nil {
+		return fmt.Errorf("block %q: %w", block.Name, err)
+	}
+	return nil
+}
+
+// checkItemWindow проверяет даты, дни недели и время окна действия элемента
+func checkItemWindow(validFrom, validUntil *time.Time, weekdays []int64, startTime, endTime string) error {
+	if validFrom != nil && validUntil != nil && validUntil.Before(*validFrom) {
+		return fmt.Errorf("item validUntil is before validFrom")
+	}
+	for _, w := range weekdays {
+		if w < model.Monday || w > model.Sunday {
+			return fmt.Errorf("invalid weekday %d", w)
+		}
+	}
+	for _, clock := range []string{startTime, endTime} {
+		if clock == "" {
+			continue
+		}
+		if _, err := utils.ParseClock(clock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeItems отбрасывает элементы, которые в момент at вне своего окна или периода показа контента;
+// day — день начала блока, к которому относится at
+func activeItems(items []model.ScheduleBlockItem, day, at time.Time) []model.ScheduleBlockItem {
+	active := make([]model.ScheduleBlockItem, 0, len(items))
+	for _, item := range items {
+		if utils.IsItemActiveAt(item, day, at) {
+			active = append(active, item)
+		}
+	}
+	return active
+}
+
+// healthyItems отбрасывает элементы, контент которых HealthChecker пометил недоступным
+func healthyItems(items []model.ScheduleBlockItem) []model.ScheduleBlockItem {
+	healthy := make([]model.ScheduleBlockItem, 0, len(items))
+	for _, item := range items {
+		if item.Content == nil || item.Content.HealthStatus != model.ContentUnhealthy {
+			healthy = append(healthy, item)
+		}
+	}
+	return healthy
+}
+
+// approvedItems отбрасывает элементы, контент которых не одобрен к показу
+func approvedItems(items []model.ScheduleBlockItem) []model.ScheduleBlockItem {
+	approved := make([]model.ScheduleBlockItem, 0, len(items))
+	for _, item := range items {
+		if item.Content != nil && item.Content.ReviewStatus == model.ReviewApproved {
+			approved = append(approved, item)
+		}
+	}
+	return approved
+}
+
+// playableItems — элементы, которые монитор может показать в момент at
+func playableItems(items []model.ScheduleBlockItem, day, at time.Time) []model.ScheduleBlockItem {
+	return healthyItems(approvedItems(activeItems(items, day, at)))
+}
+
+// buildLoop генерирует детерминированный цикл показа элементов блока.
+// sequential — один проход по Position; weighted и frequency — часовой цикл,
+// в котором количество показов каждого элемента соответствует правилам,
+// а сами показы равномерно распределены (smooth weighted round-robin).
+func buildLoop(mode model.RotationMode, items []model.ScheduleBlockItem) []model.ScheduleBlockItem {
+	ordered := make([]model.ScheduleBlockItem, len(items))
+	copy(ordered, items)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Position < ordered[j].Position })
+
+	if len(ordered) == 0 {
+		return ordered
+	}
+
+	var slots []rotationSlot
+	switch mode {
+	case model.RotationWeighted:
+		slots = weightedSlots(ordered)
+	case model.RotationFrequency:
+		slots = frequencySlots(ordered)
+	default:
+		return ordered
+	}
+	return interleave(slots)
+}
+
+// weightedSlots делит час пропорционально Weight (вес 0 считается за 1)
+func weightedSlots(items []model.ScheduleBlockItem) []rotationSlot {
+	total := 0
+	for _, item := range items {
+		total += weightOf(item)
+	}
+	slots := make([]rotationSlot, 0, len(items))
+	for _, item := range items {
+		d := itemDuration(item)
+		airtime := float64(weightOf(item)) / float64(total) * rotationWindow
+		slots = append(slots, rotationSlot{item: item, duration: d, plays: atLeastOne(airtime / float64(d))})
+	}
+	return slots
+}
+
+// frequencySlots: PlaysPerHour — фиксированное число показов, Weight — процент эфира,
+// элементы без правил поровну делят оставшееся время
+func frequencySlots(items []model.ScheduleBlockItem) []rotationSlot {
+	slots := make([]rotationSlot, 0, len(items))
+	rest := rotationWindow
+	var fillers []int
+	for _, item := range items {
+		d := itemDuration(item)
+		slot := rotationSlot{item: item, duration: d}
+		switch {
+		case item.PlaysPerHour > 0:
+			slot.plays = item.PlaysPerHour
+		case item.Weight > 0:
+			slot.plays = atLeastOne(float64(item.Weight) / 100 * rotationWindow / float64(d))
+		default:
+			fillers = append(fillers, len(slots))
+		}
+		rest -= slot.plays * d
+		slots = append(slots, slot)
+	}
+
+	if len(fillers) > 0 && rest > 0 {
+		perFiller := float64(rest) / float64(len(fillers))
+		for _, i := range fillers {
+			slots[i].plays = atLeastOne(perFiller / float64(slots[i].duration))
+		}
+	}
+	return slots
+}
+
+// interleave раскладывает показы так, чтобы одинаковые элементы шли как можно реже подряд
+func interleave(slots []rotationSlot) []model.ScheduleBlockItem {
+	total := 0
+	for _, s := range slots {
+		total += s.plays
+	}
+	current := make([]int, len(slots))
+	loop := make([]model.ScheduleBlockItem, 0, total)
+	for n := 0; n < total; n++ {
+		best := -1
+		for i, s := range slots {
+			if s.plays == 0 {
+				continue
+			}
+			current[i] += s.plays
+			if best == -1 || current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		loop = append(loop, slots[best].item)
+	}
+	return loop
+}
+
+func weightOf(item model.ScheduleBlockItem) int {
+	if item.Weight > 0 {
+		return item.Weight
+	}
+	return 1
+}
+
+func atLeastOne(v float64) int {
+	n := int(math.Round(v))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
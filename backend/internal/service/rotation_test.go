@@ -0,0 +1,140 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+func rotationItem(contentID uint, position, duration, weight, playsPerHour int) model.ScheduleBlockItem {
+	return model.ScheduleBlockItem{
+		ContentID:    contentID,
+		Position:     position,
+		Duration:     &duration,
+		Weight:       weight,
+		PlaysPerHour: playsPerHour,
+	}
+}
+
+func playCounts(loop []model.ScheduleBlockItem) map[uint]int {
+	counts := make(map[uint]int)
+	for _, item := range loop {
+		counts[item.ContentID]++
+	}
+	return counts
+}
+
+func TestBuildLoop(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  model.RotationMode
+		items []model.ScheduleBlockItem
+		want  map[uint]int
+		order []uint // ожидаемый порядок, если важен
+	}{
+		{
+			name:  "empty",
+			mode:  model.RotationWeighted,
+			items: nil,
+			want:  map[uint]int{},
+		},
+		{
+			name:  "sequential follows position",
+			mode:  model.RotationSequential,
+			items: []model.ScheduleBlockItem{rotationItem(2, 2, 10, 0, 0), rotationItem(1, 1, 10, 0, 0), rotationItem(3, 3, 10, 0, 0)},
+			want:  map[uint]int{1: 1, 2: 1, 3: 1},
+			order: []uint{1, 2, 3},
+		},
+		{
+			name:  "unknown mode plays sequentially",
+			mode:  "",
+			items: []model.ScheduleBlockItem{rotationItem(2, 2, 10, 5, 0), rotationItem(1, 1, 10, 1, 0)},
+			want:  map[uint]int{1: 1, 2: 1},
+			order: []uint{1, 2},
+		},
+		{
+			name:  "weighted splits the hour by weight",
+			mode:  model.RotationWeighted,
+			items: []model.ScheduleBlockItem{rotationItem(1, 1, 10, 3, 0), rotationItem(2, 2, 10, 1, 0)},
+			want:  map[uint]int{1: 270, 2: 90},
+		},
+		{
+			name:  "weighted treats zero weight as one",
+			mode:  model.RotationWeighted,
+			items: []model.ScheduleBlockItem{rotationItem(1, 1, 60, 0, 0), rotationItem(2, 2, 60, 1, 0)},
+			want:  map[uint]int{1: 30, 2: 30},
+		},
+		{
+			name: "frequency: plays per hour, share and fillers",
+			mode: model.RotationFrequency,
+			items: []model.ScheduleBlockItem{
+				rotationItem(1, 1, 30, 0, 4),  // 120 s
+				rotationItem(2, 2, 60, 50, 0), // 1800 s
+				rotationItem(3, 3, 60, 0, 0),  // остаток 1680 s
+			},
+			want: map[uint]int{1: 4, 2: 30, 3: 28},
+		},
+		{
+			name:  "frequency: plays per hour wins over weight",
+			mode:  model.RotationFrequency,
+			items: []model.ScheduleBlockItem{rotationItem(1, 1, 10, 90, 6), rotationItem(2, 2, 10, 0, 0)},
+			want:  map[uint]int{1: 6, 2: 354},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loop := buildLoop(tt.mode, tt.items)
+			got := playCounts(loop)
+			if len(got) != len(tt.want) {
+				t.Fatalf("plays = %v, want %v", got, tt.want)
+			}
+			for id, n := range tt.want {
+				if got[id] != n {
+					t.Fatalf("plays = %v, want %v", got, tt.want)
+				}
+			}
+			for i, id := range tt.order {
+				if loop[i].ContentID != id {
+					t.Fatalf("loop[%d] = content %d, want %d", i, loop[i].ContentID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildLoopSpreadsPlays(t *testing.T) {
+	items := []model.ScheduleBlockItem{rotationItem(1, 1, 10, 1, 0), rotationItem(2, 2, 10, 1, 0)}
+	loop := buildLoop(model.RotationWeighted, items)
+	for i := 1; i < len(loop); i++ {
+		if loop[i].ContentID == loop[i-1].ContentID {
+			t.Fatalf("content %d plays twice in a row at %d", loop[i].ContentID, i)
+		}
+	}
+}
+
+func TestValidateRotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    model.RotationMode
+		items   []model.ScheduleBlockItem
+		wantErr bool
+	}{
+		{"unknown mode", "random", nil, true},
+		{"negative weight", model.RotationWeighted, []model.ScheduleBlockItem{rotationItem(1, 1, 10, -1, 0)}, true},
+		{"shares over 100%", model.RotationFrequency, []model.ScheduleBlockItem{rotationItem(1, 1, 10, 60, 0), rotationItem(2, 2, 10, 50, 0)}, true},
+		{"plays do not fit an hour", model.RotationFrequency, []model.ScheduleBlockItem{rotationItem(1, 1, 60, 0, 61)}, true},
+		{"rules leave no time for other items", model.RotationFrequency, []model.ScheduleBlockItem{rotationItem(1, 1, 60, 0, 30), rotationItem(2, 2, 10, 50, 0), rotationItem(3, 3, 10, 0, 0)}, true},
+		{"rules leave time for other items", model.RotationFrequency, []model.ScheduleBlockItem{rotationItem(1, 1, 60, 0, 30), rotationItem(2, 2, 10, 40, 0), rotationItem(3, 3, 10, 0, 0)}, false},
+		{"plays and share fit", model.RotationFrequency, []model.ScheduleBlockItem{rotationItem(1, 1, 60, 0, 30), rotationItem(2, 2, 10, 50, 0)}, false},
+		// PlaysPerHour главнее Weight: вес такого элемента не входит в сумму долей
+		{"item with plays and weight counted once", model.RotationFrequency, []model.ScheduleBlockItem{rotationItem(1, 1, 60, 80, 30), rotationItem(2, 2, 10, 50, 0)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRotation(model.ScheduleBlock{Name: "b", RotationMode: tt.mode, Items: tt.items})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,17 +2,22 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/utils"
+	"gorm.io/gorm"
 	"time"
 )
 
 type ScheduleService struct {
-	repo *repository.ScheduleRepository
+	repo         *repository.ScheduleRepository
+	contentRepo  *repository.ContentRepository
+	playlistRepo *repository.PlaylistRepository
 }
 
-func NewScheduleService(repo *repository.ScheduleRepository) *ScheduleService {
-	return &ScheduleService{repo: repo}
+func NewScheduleService(repo *repository.ScheduleRepository, contentRepo *repository.ContentRepository, playlistRepo *repository.PlaylistRepository) *ScheduleService {
+	return &ScheduleService{repo: repo, contentRepo: contentRepo, playlistRepo: playlistRepo}
 }
 
 func (s *ScheduleService) Create(schedule *model.Schedule) error {
@@ -22,18 +27,55 @@ func (s *ScheduleService) Create(schedule *model.Schedule) error {
 	if schedule.TemplateID == 0 {
 		return errors.New("template is required")
 	}
+	// расписание закрепляется за текущей версией шаблона
+	latest, err := s.latestTemplateVersion(schedule.TemplateID)
+	if err != nil {
+		return err
+	}
+	schedule.TemplateVersion = latest
+	if err := s.validateBlocks(schedule); err != nil {
+		return err
+	}
 	return s.repo.Create(schedule)
 }
 
 func (s *ScheduleService) GetAll() ([]model.Schedule, error) {
-	return s.repo.GetAll()
+	schedules, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range schedules {
+		markDormant(&schedules[i], now)
+		markUnapproved(&schedules[i])
+	}
+	return schedules, nil
 }
 
 func (s *ScheduleService) GetByID(id uint) (*model.Schedule, error) {
-	return s.repo.GetByID(id)
+	schedule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	markDormant(schedule, time.Now())
+	markUnapproved(schedule)
+	return schedule, nil
 }
 
 func (s *ScheduleService) Update(schedule *model.Schedule) error {
+	// явная версия шаблона — перезакрепление, например после переноса изменений из новой версии
+	if schedule.TemplateVersion != 0 {
+		latest, err := s.latestTemplateVersion(schedule.TemplateID)
+		if err != nil {
+			return err
+		}
+		if schedule.TemplateVersion < 1 || schedule.TemplateVersion > latest {
+			return fmt.Errorf("template %d has no version %d", schedule.TemplateID, schedule.TemplateVersion)
+		}
+	}
+	if err := s.validateBlocks(schedule); err != nil {
+		return err
+	}
 	return s.repo.Update(schedule)
 }
 
@@ -44,3 +86,150 @@ func (s *ScheduleService) Delete(id uint) error {
 func (s *ScheduleService) GetActiveOn(date time.Time) ([]model.Schedule, error) {
 	return s.repo.GetActiveOn(date)
 }
+
+func (s *ScheduleService) latestTemplateVersion(templateID uint) (int, error) {
+	latest, err := s.repo.LatestTemplateVersion(templateID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("template %d not found", templateID)
+	}
+	return latest, err
+}
+
+// validateBlocks проверяет блоки расписания и заполняет Warnings для неодобренного контента.
+// Блоки со ссылкой на плейлист проверяются с его элементами, но сохраняются без элементов.
+func (s *ScheduleService) validateBlocks(schedule *model.Schedule) error {
+	if err := s.applyPlaylists(schedule); err != nil {
+		return err
+	}
+	defer func() {
+		for i := range schedule.Blocks {
+			if schedule.Blocks[i].PlaylistMode == model.PlaylistLink {
+				schedule.Blocks[i].Items = nil
+			}
+		}
+	}()
+	return s.checkBlocks(schedule)
+}
+
+// checkPlaylist проверяет, что расписание останется корректным, если его блоки
+// со ссылкой на плейлист получат элементы playlist
+func (s *ScheduleService) checkPlaylist(schedule *model.Schedule, playlist *model.Playlist) error {
+	for i := range schedule.Blocks {
+		b := &schedule.Blocks[i]
+		if b.PlaylistMode == model.PlaylistLink && b.PlaylistID != nil && *b.PlaylistID == playlist.ID {
+			b.Items = repository.PlaylistBlockItems(playlist, b.ID)
+		}
+	}
+	return s.checkBlocks(schedule)
+}
+
+// checkBlocks проверяет блоки с уже подставленными элементами плейлистов
+func (s *ScheduleService) checkBlocks(schedule *model.Schedule) error {
+	zones, err := s.repo.GetTemplateZones(schedule.TemplateID)
+	if err != nil {
+		return err
+	}
+	if err := checkItemZones(schedule, zones); err != nil {
+		return err
+	}
+
+	blocks := schedule.Blocks
+	var ids []uint
+	for _, block := range blocks {
+		for _, item := range block.Items {
+			ids = append(ids, item.ContentID)
+		}
+	}
+	contents, err := s.contentRepo.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	schedule.Warnings = nil
+	for _, block := range blocks {
+		// ротация считает эфирное время по длительности контента, поэтому проверяем
+		// копию блока с подставленным контентом, не трогая сохраняемые элементы
+		withContent := block
+		withContent.Items = make([]model.ScheduleBlockItem, len(block.Items))
+		for i, item := range block.Items {
+			if item.Duration == nil || *item.Duration <= 0 {
+				if err := checkContentDuration(contents, item.ContentID); err != nil {
+					return fmt.Errorf("block %q: %w", block.Name, err)
+				}
+			}
+			if c, ok := contents[item.ContentID]; ok {
+				item.Content = &c
+				if w := reviewWarning(block.Name, c); w != "" {
+					schedule.Warnings = append(schedule.Warnings, w)
+				}
+			}
+			withContent.Items[i] = item
+		}
+		if err := validateRotation(withContent); err != nil {
+			return err
+		}
+		for _, item := range block.Items {
+			if err := validateItemWindow(block, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyPlaylists подставляет в блоки элементы плейлистов: в блоки со ссылкой — всегда,
+// в блоки со снимком — если у них нет своих элементов
+func (s *ScheduleService) applyPlaylists(schedule *model.Schedule) error {
+	var ids []uint
+	for i := range schedule.Blocks {
+		b := &schedule.Blocks[i]
+		if err := checkPlaylistMode(b.Name, b.PlaylistID, &b.PlaylistMode); err != nil {
+			return err
+		}
+		if b.PlaylistID != nil {
+			ids = append(ids, *b.PlaylistID)
+		}
+	}
+	playlists, err := playlistsFor(s.playlistRepo, ids)
+	if err != nil {
+		return err
+	}
+	for i := range schedule.Blocks {
+		b := &schedule.Blocks[i]
+		if b.PlaylistID == nil || (b.PlaylistMode == model.PlaylistSnapshot && len(b.Items) > 0) {
+			continue
+		}
+		playlist := playlists[*b.PlaylistID]
+		b.Items = repository.PlaylistBlockItems(&playlist, b.ID)
+		for j := range b.Items {
+			b.Items[j].Content = nil // контент только по ContentID
+		}
+	}
+	return nil
+}
+
+// markUnapproved заполняет Warnings для контента блоков, который не попадёт в эфир без согласования
+func markUnapproved(schedule *model.Schedule) {
+	schedule.Warnings = nil
+	for _, block := range schedule.Blocks {
+		for _, item := range block.Items {
+			if item.Content == nil {
+				continue
+			}
+			if w := reviewWarning(block.Name, *item.Content); w != "" {
+				schedule.Warnings = append(schedule.Warnings, w)
+			}
+		}
+	}
+}
+
+// markDormant помечает элементы блоков, которые сейчас вне своего окна действия
+func markDormant(schedule *model.Schedule, now time.Time) {
+	for i := range schedule.Blocks {
+		day := utils.BlockStartDay(schedule.Blocks[i], now)
+		items := schedule.Blocks[i].Items
+		for j := range items {
+			items[j].Dormant = !utils.IsItemActiveAt(items[j], day, now)
+		}
+	}
+}
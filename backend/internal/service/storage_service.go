@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+const (
+	DefaultStorageListLimit = 20
+	MaxStorageListLimit     = 200
+)
+
+// StorageService учитывает место, занятое файлами контента, по локациям-владельцам
+// и не даёт загрузить файл сверх квоты локации. Позже владельцем станет и арендатор:
+// его квота проверяется так же, поверх квот его локаций.
+type StorageService struct {
+	repo         *repository.StorageRepository
+	locationRepo *repository.LocationRepository
+	defaultQuota int64
+}
+
+func NewStorageService(repo *repository.StorageRepository, locationRepo *repository.LocationRepository, defaultQuota int64) *StorageService {
+	return &StorageService{repo: repo, locationRepo: locationRepo, defaultQuota: defaultQuota}
+}
+
+// LocationStorage — место, занятое контентом локации; Quota 0 — без ограничения
+type LocationStorage struct {
+	LocationID   uint   `json:"locationId"`
+	LocationName string `json:"locationName"`
+	UsedBytes    int64  `json:"usedBytes"`
+	Quota        int64  `json:"quota"`
+	Files        int64  `json:"files"`
+	Contents     int64  `json:"contents"`
+}
+
+// StorageUsage — занятое место по локациям. Shared — контент без локации.
+// Один файл может принадлежать нескольким локациям, поэтому TotalBytes
+// (реально занятое в хранилище) может быть меньше суммы по локациям.
+type StorageUsage struct {
+	Locations  []LocationStorage     `json:"locations"`
+	Shared     repository.OwnerUsage `json:"shared"`
+	TotalBytes int64                 `json:"totalBytes"`
+}
+
+// CheckQuota проверяет, поместятся ли файлы в квоту локации. Файлы, которые уже
+// есть у локации, места не добавляют; locationID nil — общий контент без квоты.
+// Это предварительная проверка: окончательную при сохранении выполняет CheckQuotaTx.
+func (s *StorageService) CheckQuota(locationID *uint, files ...*StoredFile) error {
+	if locationID == nil {
+		return nil
+	}
+	location, err := s.locationRepo.GetByID(*locationID)
+	if err != nil {
+		return quotaLocationError(*locationID, err)
+	}
+	return s.checkQuota(s.repo, location, files)
+}
+
+// CheckQuotaTx проверяет квоту внутри транзакции tx, в которой сохраняется контент.
+// Строка локации блокируется до конца транзакции, поэтому параллельные загрузки
+// в одну локацию проверяются по очереди и вместе не превысят квоту.
+func (s *StorageService) CheckQuotaTx(tx *repository.ContentRepository, locationID *uint, files ...*StoredFile) error {
+	if locationID == nil {
+		return nil
+	}
+	repo := tx.Storage()
+	location, err := repo.LockLocation(*locationID)
+	if err != nil {
+		return quotaLocationError(*locationID, err)
+	}
+	return s.checkQuota(repo, location, files)
+}
+
+func quotaLocationError(id uint, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: location %d not found", ErrInvalidContent, id)
+	}
+	return err
+}
+
+func (s *StorageService) checkQuota(repo *repository.StorageRepository, location *model.Location, files []*StoredFile) error {
+	quota := s.quotaOf(location)
+	if quota == 0 {
+		return nil
+	}
+
+	var added int64
+	counted := make(map[string]bool, len(files))
+	for _, f := range files {
+		if counted[f.Checksum] {
+			continue
+		}
+		counted[f.Checksum] = true
+		has, err := repo.LocationHasFile(location.ID, f.Checksum)
+		if err != nil {
+			return err
+		}
+		if !has {
+			added += f.Size
+		}
+	}
+	if added == 0 {
+		return nil
+	}
+	used, err := repo.LocationUsage(location.ID)
+	if err != nil {
+		return err
+	}
+	if used+added > quota {
+		return fmt.Errorf("%w: location %q uses %d of %d bytes, %d more requested",
+			ErrQuotaExceeded, location.Name, used, quota, added)
+	}
+	return nil
+}
+
+// Usage возвращает занятое место по всем локациям, включая локации без контента
+func (s *StorageService) Usage() (*StorageUsage, error) {
+	owners, err := s.repo.UsageByLocation()
+	if err != nil {
+		return nil, err
+	}
+	locations, err := s.locationRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.TotalUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	byLocation := make(map[uint]repository.OwnerUsage, len(owners))
+	usage := &StorageUsage{Locations: make([]LocationStorage, 0, len(locations)), TotalBytes: total}
+	for _, o := range owners {
+		if o.LocationID == nil {
+			usage.Shared = o
+			continue
+		}
+		byLocation[*o.LocationID] = o
+	}
+	for i := range locations {
+		l := &locations[i]
+		o := byLocation[l.ID]
+		usage.Locations = append(usage.Locations, LocationStorage{
+			LocationID:   l.ID,
+			LocationName: l.Name,
+			UsedBytes:    o.UsedBytes,
+			Quota:        s.quotaOf(l),
+			Files:        o.Files,
+			Contents:     o.Contents,
+		})
+	}
+	return usage, nil
+}
+
+// Largest возвращает контент с самыми большими файлами
+func (s *StorageService) Largest(locationID *uint, limit int) ([]model.Content, error) {
+	return s.repo.Largest(locationID, storageListLimit(limit))
+}
+
+// Unused возвращает контент с файлами, который нигде не используется, — кандидатов на удаление
+func (s *StorageService) Unused(locationID *uint, limit int) ([]model.Content, error) {
+	return s.repo.Unused(locationID, storageListLimit(limit))
+}
+
+func (s *StorageService) quotaOf(location *model.Location) int64 {
+	if location.StorageQuota > 0 {
+		return location.StorageQuota
+	}
+	return s.defaultQuota
+}
+
+func storageListLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultStorageListLimit
+	}
+	return min(limit, MaxStorageListLimit)
+}
@@ -0,0 +1,568 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+const (
+	templateBundleFormat = 1
+	bundleTemplateName   = "template.json"
+	bundleMediaDir       = "media/"
+	maxBundleJSONSize    = 16 << 20
+)
+
+// Outcome of a bundle content on import
+const (
+	BundleMatched = "matched" // an existing content is reused
+	BundleCreated = "created"
+)
+
+// Bundle conflict kinds. All of them except ConflictContentDiffers stop the import.
+const (
+	ConflictTemplateExists   = "template_exists"
+	ConflictMissingContent   = "missing_content"
+	ConflictMissingMedia     = "missing_media"
+	ConflictChecksumMismatch = "checksum_mismatch"
+	ConflictInvalidContent   = "invalid_content"
+	ConflictInvalidTemplate  = "invalid_template"
+	ConflictContentDiffers   = "content_differs"
+)
+
+var (
+	ErrInvalidBundle       = errors.New("invalid template bundle")
+	ErrBundleConflict      = errors.New("template bundle has conflicts")
+	ErrBundleMediaNotFound = errors.New("media file of the template is missing from storage")
+)
+
+// TemplateBundle is a portable copy of a template: IDs refer to the source environment
+// and are remapped on import. Playlists are not exported: linked blocks carry the items
+// they showed at export time, and zones are exported without their playlists.
+type TemplateBundle struct {
+	Format     int             `json:"format"`
+	ExportedAt time.Time       `json:"exportedAt"`
+	Template   model.Template  `json:"template"`
+	Contents   []BundleContent `json:"contents"`
+}
+
+// BundleContent is the metadata of a content the template refers to.
+// Uploaded files are identified by checksum; File is set when the ZIP bundle carries the file.
+type BundleContent struct {
+	ID              uint       `json:"id"`
+	Title           string     `json:"title"`
+	Type            string     `json:"type"`
+	Path            string     `json:"path,omitempty"` // URL of web pages, streams and feeds
+	Description     string     `json:"description,omitempty"`
+	Duration        int        `json:"duration,omitempty"`
+	Body            string     `json:"body,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
+	ValidFrom       *time.Time `json:"validFrom,omitempty"`
+	ValidUntil      *time.Time `json:"validUntil,omitempty"`
+	Checksum        string     `json:"checksum,omitempty"`
+	Size            int64      `json:"size,omitempty"`
+	MimeType        string     `json:"mimeType,omitempty"`
+	FitMode         string     `json:"fitMode,omitempty"`
+	FocusX          *float64   `json:"focusX,omitempty"`
+	FocusY          *float64   `json:"focusY,omitempty"`
+	RefreshInterval int        `json:"refreshInterval,omitempty"`
+	Zoom            float64    `json:"zoom,omitempty"`
+	FeedMaxItems    int        `json:"feedMaxItems,omitempty"`
+	FeedFilter      string     `json:"feedFilter,omitempty"`
+	File            string     `json:"file,omitempty"`
+}
+
+// BundleImportOptions — Name overrides the template name from the bundle
+type BundleImportOptions struct {
+	Name       string
+	UploadedBy string
+}
+
+type BundleContentResult struct {
+	SourceID  uint   `json:"sourceId"`
+	Title     string `json:"title"`
+	Status    string `json:"status,omitempty"` // matched | created; empty when the import stopped
+	ContentID uint   `json:"contentId,omitempty"`
+}
+
+type BundleConflict struct {
+	Kind     string `json:"kind"`
+	SourceID uint   `json:"sourceId,omitempty"`
+	Message  string `json:"message"`
+}
+
+type BundleImportReport struct {
+	TemplateID uint                  `json:"templateId,omitempty"`
+	Name       string                `json:"name"`
+	Contents   []BundleContentResult `json:"contents"`
+	Conflicts  []BundleConflict      `json:"conflicts"`
+	Warnings   []string              `json:"warnings,omitempty"`
+}
+
+func (r *BundleImportReport) conflict(kind string, sourceID uint, format string, args ...interface{}) {
+	r.Conflicts = append(r.Conflicts, BundleConflict{Kind: kind, SourceID: sourceID, Message: fmt.Sprintf(format, args...)})
+}
+
+// blocked reports whether any conflict stops the import
+func (r *BundleImportReport) blocked() bool {
+	for _, c := range r.Conflicts {
+		if c.Kind != ConflictContentDiffers {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateBundleService moves templates between environments as JSON or ZIP bundles
+type TemplateBundleService struct {
+	templates *TemplateService
+	contents  *ContentService
+	maxSize   int64
+}
+
+func NewTemplateBundleService(templates *TemplateService, contents *ContentService, maxSize int64) *TemplateBundleService {
+	return &TemplateBundleService{templates: templates, contents: contents, maxSize: maxSize}
+}
+
+// MaxBundleSize is the limit for an uploaded bundle in bytes
+func (s *TemplateBundleService) MaxBundleSize() int64 {
+	return s.maxSize
+}
+
+// Export builds a bundle of the template and the contents its blocks refer to.
+// Playlists stay in this environment: linked blocks are exported with their current
+// contents and zones lose their playlists.
+func (s *TemplateBundleService) Export(id uint) (*TemplateBundle, error) {
+	template, err := s.templates.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for i := range template.Blocks {
+		b := &template.Blocks[i]
+		b.PlaylistID, b.Playlist, b.PlaylistMode = nil, nil, ""
+		for _, c := range b.Contents {
+			ids = append(ids, c.ContentID)
+		}
+	}
+	for i := range template.Zones {
+		template.Zones[i].PlaylistID, template.Zones[i].Playlist = nil, nil
+	}
+	template.Warnings = nil
+	contents, err := s.contents.repo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &TemplateBundle{
+		Format:     templateBundleFormat,
+		ExportedAt: time.Now(),
+		Template:   *template,
+		Contents:   make([]BundleContent, 0, len(contents)),
+	}
+	for _, c := range contents {
+		bundle.Contents = append(bundle.Contents, newBundleContent(c))
+	}
+	sort.Slice(bundle.Contents, func(i, j int) bool { return bundle.Contents[i].ID < bundle.Contents[j].ID })
+	return bundle, nil
+}
+
+// CheckMedia makes sure every uploaded file of the bundle is in storage. The ZIP is
+// streamed to the client, so this has to run before the response is started.
+func (s *TemplateBundleService) CheckMedia(bundle *TemplateBundle) error {
+	checked := make(map[string]bool)
+	for _, c := range bundle.Contents {
+		if c.Checksum == "" || checked[c.Checksum] {
+			continue
+		}
+		checked[c.Checksum] = true
+		_, err := s.contents.store.Stat(mediaKey(c.Checksum))
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("%w: content %d %q: file %s", ErrBundleMediaNotFound, c.ID, c.Title, c.Checksum)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteZip writes the bundle as a ZIP archive: template.json and, with media,
+// the uploaded files under media/<checksum>
+func (s *TemplateBundleService) WriteZip(w io.Writer, bundle *TemplateBundle, withMedia bool) error {
+	var checksums []string
+	if withMedia {
+		seen := make(map[string]bool)
+		for i := range bundle.Contents {
+			c := &bundle.Contents[i]
+			if c.Checksum == "" {
+				continue
+			}
+			c.File = bundleMediaDir + c.Checksum
+			if !seen[c.Checksum] {
+				seen[c.Checksum] = true
+				checksums = append(checksums, c.Checksum)
+			}
+		}
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	fw, err := zw.Create(bundleTemplateName)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return err
+	}
+	for _, checksum := range checksums {
+		if err := s.writeMedia(zw, checksum); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// writeMedia copies a stored file into the archive; media is already compressed, so it is stored as is
+func (s *TemplateBundleService) writeMedia(zw *zip.Writer, checksum string) error {
+	r, err := s.contents.store.Get(mediaKey(checksum))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: bundleMediaDir + checksum, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+// bundlePlan is a bundle content that has to be created
+type bundlePlan struct {
+	index   int
+	source  BundleContent
+	content *model.Content
+	file    *zip.File
+}
+
+// Import creates the template from a JSON or ZIP bundle. Contents are matched to the
+// library by checksum (uploaded files) or by type and URL; the rest are created, uploaded
+// files only when the bundle carries them. New contents and the template are written in
+// one transaction, and the template is validated against them before it is saved: on a
+// conflict or an invalid template nothing is written and the report lists every problem.
+func (s *TemplateBundleService) Import(r io.ReaderAt, size int64, opts BundleImportOptions) (*BundleImportReport, error) {
+	bundle, files, err := readBundle(r, size)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(opts.Name)
+	if name == "" {
+		name = bundle.Template.Name
+	}
+	report := &BundleImportReport{
+		Name:      name,
+		Contents:  make([]BundleContentResult, len(bundle.Contents)),
+		Conflicts: []BundleConflict{},
+	}
+
+	exists, err := s.templates.repo.NameExists(name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		report.conflict(ConflictTemplateExists, 0, "template %q already exists; import it under another name", name)
+	}
+	sources := make(map[uint]bool, len(bundle.Contents))
+	for _, c := range bundle.Contents {
+		sources[c.ID] = true
+	}
+	for _, b := range bundle.Template.Blocks {
+		for _, c := range b.Contents {
+			if !sources[c.ContentID] {
+				report.conflict(ConflictMissingContent, c.ContentID, "block %q: content %d is not in the bundle", b.Name, c.ContentID)
+			}
+		}
+	}
+
+	remap := make(map[uint]uint, len(bundle.Contents))
+	var plans []bundlePlan
+	for i, bc := range bundle.Contents {
+		report.Contents[i] = BundleContentResult{SourceID: bc.ID, Title: bc.Title}
+		existing, err := s.matchContent(bc)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			report.Contents[i].Status, report.Contents[i].ContentID = BundleMatched, existing.ID
+			remap[bc.ID] = existing.ID
+			if existing.Title != bc.Title || existing.Type != bc.Type {
+				report.conflict(ConflictContentDiffers, bc.ID, "content %d %q (%s) matches existing content %d %q (%s)",
+					bc.ID, bc.Title, bc.Type, existing.ID, existing.Title, existing.Type)
+			}
+			continue
+		}
+
+		plan := bundlePlan{index: i, source: bc, content: bc.content()}
+		if bc.Checksum != "" {
+			if bc.File != "" {
+				plan.file = files[path.Clean(bc.File)]
+			}
+			if plan.file == nil {
+				report.conflict(ConflictMissingMedia, bc.ID, "content %d %q: file %s is not in the library and not in the bundle",
+					bc.ID, bc.Title, bc.Checksum)
+				continue
+			}
+		} else if err := s.contents.validate(plan.content); err != nil {
+			report.conflict(ConflictInvalidContent, bc.ID, "content %d %q: %v", bc.ID, bc.Title, err)
+			continue
+		}
+		plans = append(plans, plan)
+	}
+	if report.blocked() {
+		return report, ErrBundleConflict
+	}
+
+	var stored []*StoredFile
+	release := func() {
+		for _, f := range stored {
+			s.contents.releaseObjects(f.Checksum, f.Thumbnail)
+		}
+	}
+	for _, p := range plans {
+		if p.file == nil {
+			resetReview(p.content)
+			continue
+		}
+		file, err := s.storeMedia(p.file)
+		if err != nil {
+			report.conflict(ConflictInvalidContent, p.source.ID, "content %d %q: %v", p.source.ID, p.source.Title, err)
+			continue
+		}
+		stored = append(stored, file)
+		if file.Checksum != p.source.Checksum {
+			report.conflict(ConflictChecksumMismatch, p.source.ID, "content %d %q: file %s has checksum %s",
+				p.source.ID, p.source.Title, p.file.Name, file.Checksum)
+			continue
+		}
+		if err := s.contents.prepareUploaded(p.content, file); err != nil {
+			report.conflict(ConflictInvalidContent, p.source.ID, "content %d %q: %v", p.source.ID, p.source.Title, err)
+		}
+	}
+	if report.blocked() {
+		release()
+		return report, ErrBundleConflict
+	}
+
+	var template *model.Template
+	err = s.contents.repo.Transaction(func(tx *repository.ContentRepository) error {
+		for _, p := range plans {
+			if p.file != nil {
+				if err := saveUploaded(tx, p.content, opts.UploadedBy); err != nil {
+					return err
+				}
+			} else if err := tx.Create(p.content); err != nil {
+				return err
+			}
+			remap[p.source.ID] = p.content.ID
+		}
+
+		templates := s.templates.withTx(tx)
+		template = remapTemplate(&bundle.Template, name, remap)
+		validation, err := templates.Validate(template)
+		if err != nil {
+			return err
+		}
+		sources := make(map[uint]uint, len(remap))
+		for source, id := range remap {
+			sources[id] = source
+		}
+		for _, d := range validation.Diagnostics {
+			if d.Severity == SeverityError {
+				report.conflict(ConflictInvalidTemplate, sources[d.ContentID], "%s", d.Message)
+			}
+		}
+		if report.blocked() {
+			return ErrBundleConflict
+		}
+		return templates.CreateTemplate(template)
+	})
+	if err != nil {
+		release()
+		if errors.Is(err, ErrBundleConflict) {
+			return report, err
+		}
+		return nil, err
+	}
+	for _, p := range plans {
+		report.Contents[p.index].Status, report.Contents[p.index].ContentID = BundleCreated, p.content.ID
+	}
+	report.TemplateID = template.ID
+	report.Warnings = template.Warnings
+	return report, nil
+}
+
+// matchContent finds a library content for a bundle content: uploaded files by checksum,
+// web pages, streams and feeds by type and URL; nil when there is none
+func (s *TemplateBundleService) matchContent(bc BundleContent) (*model.Content, error) {
+	var content *model.Content
+	var err error
+	switch {
+	case bc.Checksum != "":
+		content, err = s.contents.repo.GetByChecksum(bc.Checksum)
+	case bc.Path != "":
+		content, err = s.contents.repo.GetByTypeAndPath(bc.Type, bc.Path)
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return content, err
+}
+
+func (s *TemplateBundleService) storeMedia(f *zip.File) (*StoredFile, error) {
+	if f.UncompressedSize64 > uint64(s.contents.MaxUploadSize()) {
+		return nil, ErrFileTooLarge
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return s.contents.StoreFile(rc)
+}
+
+// readBundle reads a ZIP bundle (template.json plus media files) or a bare JSON bundle
+func readBundle(r io.ReaderAt, size int64) (*TemplateBundle, map[string]*zip.File, error) {
+	var data []byte
+	files := make(map[string]*zip.File)
+	if zr, err := zip.NewReader(r, size); err == nil {
+		for _, f := range zr.File {
+			name := path.Clean(f.Name)
+			if name != bundleTemplateName {
+				files[name] = f
+				continue
+			}
+			if data, err = readZipFile(f, maxBundleJSONSize); err != nil {
+				return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, name, err)
+			}
+		}
+		if data == nil {
+			return nil, nil, fmt.Errorf("%w: %s is missing", ErrInvalidBundle, bundleTemplateName)
+		}
+	} else {
+		if size > maxBundleJSONSize {
+			return nil, nil, fmt.Errorf("%w: JSON bundle is too large", ErrInvalidBundle)
+		}
+		data = make([]byte, size)
+		if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+	}
+
+	var bundle TemplateBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if bundle.Format != templateBundleFormat {
+		return nil, nil, fmt.Errorf("%w: unsupported format %d", ErrInvalidBundle, bundle.Format)
+	}
+	return &bundle, files, nil
+}
+
+func newBundleContent(c model.Content) BundleContent {
+	bc := BundleContent{
+		ID:              c.ID,
+		Title:           c.Title,
+		Type:            c.Type,
+		Description:     c.Description,
+		Duration:        c.Duration,
+		Body:            c.Body,
+		Tags:            c.Tags,
+		ValidFrom:       c.ValidFrom,
+		ValidUntil:      c.ValidUntil,
+		Checksum:        c.Checksum,
+		Size:            c.Size,
+		MimeType:        c.MimeType,
+		FitMode:         c.FitMode,
+		FocusX:          c.FocusX,
+		FocusY:          c.FocusY,
+		RefreshInterval: c.RefreshInterval,
+		Zoom:            c.Zoom,
+		FeedMaxItems:    c.FeedMaxItems,
+		FeedFilter:      c.FeedFilter,
+	}
+	// the path of an uploaded file is a download link of this environment
+	if c.Checksum == "" {
+		bc.Path = c.Path
+	}
+	return bc
+}
+
+// content is a new library content for the bundle content; file details are filled on upload
+func (bc BundleContent) content() *model.Content {
+	return &model.Content{
+		Title:           bc.Title,
+		Type:            bc.Type,
+		Path:            bc.Path,
+		Description:     bc.Description,
+		Duration:        bc.Duration,
+		Body:            bc.Body,
+		Tags:            bc.Tags,
+		ValidFrom:       bc.ValidFrom,
+		ValidUntil:      bc.ValidUntil,
+		FitMode:         bc.FitMode,
+		FocusX:          bc.FocusX,
+		FocusY:          bc.FocusY,
+		RefreshInterval: bc.RefreshInterval,
+		Zoom:            bc.Zoom,
+		FeedMaxItems:    bc.FeedMaxItems,
+		FeedFilter:      bc.FeedFilter,
+	}
+}
+
+// remapTemplate copies the bundled template with new IDs and contents of this environment
+func remapTemplate(source *model.Template, name string, remap map[uint]uint) *model.Template {
+	template := &model.Template{
+		Name:         name,
+		Description:  source.Description,
+		CanvasWidth:  source.CanvasWidth,
+		CanvasHeight: source.CanvasHeight,
+	}
+	for _, z := range source.Zones {
+		z.ID, z.TemplateID, z.PlaylistID, z.Playlist = 0, 0, nil, nil
+		template.Zones = append(template.Zones, z)
+	}
+	for _, b := range source.Blocks {
+		block := model.TemplateBlock{Name: b.Name, StartTime: b.StartTime, EndTime: b.EndTime}
+		for _, c := range b.Contents {
+			block.Contents = append(block.Contents, model.TemplateContent{
+				ContentID: remap[c.ContentID],
+				Order:     c.Order,
+				Duration:  c.Duration,
+				Zone:      c.Zone,
+			})
+		}
+		template.Blocks = append(template.Blocks, block)
+	}
+	return template
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/storage"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCheckMedia(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir(), "http://signage.local/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	present := strings.Repeat("a", 64)
+	if err := store.Put(mediaKey(present), strings.NewReader("data"), 4, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	s := &TemplateBundleService{contents: &ContentService{store: store}}
+
+	bundle := &TemplateBundle{Contents: []BundleContent{
+		{ID: 1, Title: "Логотип", Checksum: present},
+		{ID: 2, Title: "Сайт", Type: model.ContentTypeWeb, Path: "https://example.com"},
+	}}
+	if err := s.CheckMedia(bundle); err != nil {
+		t.Fatalf("CheckMedia: %v", err)
+	}
+
+	bundle.Contents = append(bundle.Contents, BundleContent{ID: 3, Title: "Видео", Checksum: strings.Repeat("b", 64)})
+	if err := s.CheckMedia(bundle); !errors.Is(err, ErrBundleMediaNotFound) {
+		t.Fatalf("err = %v, want ErrBundleMediaNotFound", err)
+	}
+}
+
+func TestRemapTemplateDropsZonePlaylists(t *testing.T) {
+	playlist := uint(4)
+	source := &model.Template{
+		Name:  "Витрина",
+		Zones: []model.TemplateZone{{ID: 2, TemplateID: 1, Name: "promo", Width: 10, Height: 10, PlaylistID: &playlist}},
+		Blocks: []model.TemplateBlock{{ID: 3, Name: "День",
+			Contents: []model.TemplateContent{{ID: 5, ContentID: 7, Order: 1, Zone: "promo"}}}},
+	}
+	template := remapTemplate(source, "Витрина 2", map[uint]uint{7: 70})
+	if template.Name != "Витрина 2" || template.Zones[0].ID != 0 || template.Zones[0].PlaylistID != nil {
+		t.Fatalf("zones = %+v", template.Zones)
+	}
+	if c := template.Blocks[0].Contents[0]; c.ContentID != 70 || c.ID != 0 || c.Zone != "promo" {
+		t.Fatalf("contents = %+v", template.Blocks[0].Contents)
+	}
+}
+
+// scriptedDB is gorm on top of a database/sql driver that answers every query with
+// answer(query, args); statements succeed and transactions do nothing
+func scriptedDB(t *testing.T, answer func(query string, args []driver.NamedValue) ([]string, [][]driver.Value)) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(scriptedConn{answer})}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+type scriptedConn struct {
+	answer func(query string, args []driver.NamedValue) ([]string, [][]driver.Value)
+}
+
+func (c scriptedConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c scriptedConn) Driver() driver.Driver                        { return nil }
+func (c scriptedConn) Prepare(string) (driver.Stmt, error)          { return nil, errors.ErrUnsupported }
+func (c scriptedConn) Close() error                                 { return nil }
+func (c scriptedConn) Begin() (driver.Tx, error)                    { return c, nil }
+func (c scriptedConn) Commit() error                                { return nil }
+func (c scriptedConn) Rollback() error                              { return nil }
+
+func (c scriptedConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (c scriptedConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	columns, rows := c.answer(query, args)
+	return &scriptedRows{columns: columns, rows: rows}, nil
+}
+
+type scriptedRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *scriptedRows) Columns() []string { return r.columns }
+func (r *scriptedRows) Close() error      { return nil }
+
+func (r *scriptedRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// bundleLibrary answers the queries of Import: templates named taken exist, contents are
+// found by checksum or by type and URL, and nothing else is in use
+func bundleLibrary(taken string, contents ...model.Content) func(string, []driver.NamedValue) ([]string, [][]driver.Value) {
+	return func(query string, args []driver.NamedValue) ([]string, [][]driver.Value) {
+		arg := func(i int) interface{} { return args[i].Value }
+		switch {
+		case strings.Contains(query, `FROM "templates"`):
+			if arg(0) == taken {
+				return []string{"count"}, [][]driver.Value{{int64(1)}}
+			}
+			return []string{"count"}, [][]driver.Value{{int64(0)}}
+		case strings.Contains(query, "checksum = $1 ORDER BY"), strings.Contains(query, "type = $1 AND path = $2"):
+			for _, c := range contents {
+				if c.Checksum != "" && c.Checksum == arg(0) || c.Path != "" && c.Type == arg(0) && c.Path == arg(1) {
+					return []string{"id", "title", "type", "checksum", "path"},
+						[][]driver.Value{{int64(c.ID), c.Title, c.Type, c.Checksum, c.Path}}
+				}
+			}
+			return []string{"id"}, nil
+		case strings.Contains(query, "count(*)"):
+			return []string{"count"}, [][]driver.Value{{int64(0)}}
+		}
+		return []string{"id"}, nil
+	}
+}
+
+func TestImportMatchesContentsAndReportsConflicts(t *testing.T) {
+	logo, menu := strings.Repeat("a", 64), "https://example.com/menu"
+	db := scriptedDB(t, bundleLibrary("Витрина",
+		model.Content{ID: 10, Title: "Логотип", Type: model.ContentTypeImage, Checksum: logo},
+		model.Content{ID: 11, Title: "Старое меню", Type: model.ContentTypeWeb, Path: menu},
+	))
+	contents := repository.NewContentRepository(db)
+	s := NewTemplateBundleService(&TemplateService{repo: contents.Templates(), contentRepo: contents, playlistRepo: contents.Playlists()},
+		&ContentService{repo: contents}, 1<<20)
+
+	bundle := TemplateBundle{Format: templateBundleFormat,
+		Template: model.Template{Name: "Витрина", Blocks: []model.TemplateBlock{{Name: "День", Contents: []model.TemplateContent{
+			{ContentID: 1}, {ContentID: 2}, {ContentID: 3},
+		}}}},
+		Contents: []BundleContent{
+			{ID: 1, Title: "Логотип", Type: model.ContentTypeImage, Checksum: logo},
+			{ID: 2, Title: "Меню", Type: model.ContentTypeWeb, Path: menu},
+			{ID: 4, Title: "Видео", Type: model.ContentTypeVideo, Checksum: strings.Repeat("b", 64)},
+		},
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.Import(bytes.NewReader(data), int64(len(data)), BundleImportOptions{})
+	if !errors.Is(err, ErrBundleConflict) {
+		t.Fatalf("err = %v, want ErrBundleConflict", err)
+	}
+	want := []BundleContentResult{
+		{SourceID: 1, Title: "Логотип", Status: BundleMatched, ContentID: 10},
+		{SourceID: 2, Title: "Меню", Status: BundleMatched, ContentID: 11},
+		{SourceID: 4, Title: "Видео"},
+	}
+	if !reflect.DeepEqual(report.Contents, want) {
+		t.Fatalf("contents = %+v", report.Contents)
+	}
+	var kinds []string
+	for _, c := range report.Conflicts {
+		kinds = append(kinds, fmt.Sprintf("%s:%d", c.Kind, c.SourceID))
+	}
+	wantKinds := []string{ConflictTemplateExists + ":0", ConflictMissingContent + ":3", ConflictContentDiffers + ":2", ConflictMissingMedia + ":4"}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Fatalf("conflicts = %v, want %v", kinds, wantKinds)
+	}
+}
+
+func TestImportReleasesStoredMediaOnConflict(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir(), "http://signage.local/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := repository.NewContentRepository(scriptedDB(t, bundleLibrary("")))
+	s := NewTemplateBundleService(&TemplateService{repo: contents.Templates(), contentRepo: contents, playlistRepo: contents.Playlists()},
+		&ContentService{repo: contents, store: store, maxUploadSize: 1 << 20}, 1<<20)
+
+	declared := strings.Repeat("c", 64)
+	file := []byte("not the file the bundle declares")
+	sum := sha256.Sum256(file)
+	actual := hex.EncodeToString(sum[:])
+	bundle := TemplateBundle{Format: templateBundleFormat,
+		Template: model.Template{Name: "Витрина", Blocks: []model.TemplateBlock{{Name: "День", Contents: []model.TemplateContent{{ContentID: 1}}}}},
+		Contents: []BundleContent{{ID: 1, Title: "Заметка", Type: model.ContentTypeImage, Checksum: declared, File: bundleMediaDir + declared}},
+	}
+	data := zipBundle(t, bundle, map[string][]byte{bundleMediaDir + declared: file})
+
+	report, err := s.Import(bytes.NewReader(data), int64(len(data)), BundleImportOptions{})
+	if !errors.Is(err, ErrBundleConflict) || len(report.Conflicts) != 1 || report.Conflicts[0].Kind != ConflictChecksumMismatch {
+		t.Fatalf("err = %v, report = %+v", err, report)
+	}
+	if exists, err := storage.Exists(store, mediaKey(actual)); err != nil || exists {
+		t.Fatalf("stored file kept after the conflict (err %v)", err)
+	}
+}
+
+func TestReadBundle(t *testing.T) {
+	bundle := TemplateBundle{Format: templateBundleFormat, Template: model.Template{Name: "Витрина"}}
+	jsonData, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	future := bundle
+	future.Format = templateBundleFormat + 1
+	futureData, err := json.Marshal(future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var noTemplate bytes.Buffer
+	zw := zip.NewWriter(&noTemplate)
+	if _, err := zw.Create(bundleMediaDir + "x"); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		files   []string
+		wantErr bool
+	}{
+		{"json", jsonData, nil, false},
+		{"zip with media", zipBundle(t, bundle, map[string][]byte{"./media/abc": []byte("x")}), []string{"media/abc"}, false},
+		{"zip without template.json", noTemplate.Bytes(), nil, true},
+		{"unsupported format", futureData, nil, true},
+		{"not a bundle", []byte("template"), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, files, err := readBundle(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidBundle) {
+					t.Fatalf("err = %v, want ErrInvalidBundle", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Template.Name != "Витрина" || len(files) != len(tt.files) {
+				t.Fatalf("bundle = %+v, files = %v", got, files)
+			}
+			for _, name := range tt.files {
+				if files[name] == nil {
+					t.Fatalf("files = %v, want %v", files, tt.files)
+				}
+			}
+		})
+	}
+}
+
+// zipBundle builds a ZIP bundle of the template and the given files
+func zipBundle(t *testing.T, bundle TemplateBundle, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.Create(bundleTemplateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewEncoder(fw).Encode(bundle); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"   // the template cannot be saved as is
+	SeverityWarning = "warning" // saved, but plays differently than it probably should
+	SeverityInfo    = "info"
+)
+
+// Diagnostic codes
+const (
+	DiagNoBlocks             = "no_blocks"
+	DiagMissingTime          = "missing_time"
+	DiagInvalidTimeRange     = "invalid_time_range"
+	DiagOverlappingBlocks    = "overlapping_blocks"
+	DiagEmptyBlock           = "empty_block"
+	DiagPlaylistNotFound     = "playlist_not_found"
+	DiagInvalidPlaylistMode  = "invalid_playlist_mode"
+	DiagPlaylistItemWindow   = "playlist_item_window"
+	DiagMissingContent       = "missing_content"
+	DiagNoDuration           = "no_duration"
+	DiagImageWithoutDuration = "image_without_duration"
+	DiagUnapprovedContent    = "unapproved_content"
+	DiagPlaylistTooLong      = "playlist_too_long"
+	DiagPlaylistTooShort     = "playlist_too_short"
+	DiagUncoveredHours       = "uncovered_hours"
+	DiagInvalidLayout        = "invalid_layout"
+)
+
+const minutesPerDay = 24 * 60
+
+// Diagnostic is one finding about a template. Path points at the offending part,
+// e.g. "blocks[1]" or "blocks[1].contents[0]"; empty for the template as a whole.
+type Diagnostic struct {
+	Severity  string `json:"severity"`
+	Code      string `json:"code"`
+	Path      string `json:"path,omitempty"`
+	Block     string `json:"block,omitempty"`
+	ContentID uint   `json:"contentId,omitempty"`
+	Message   string `json:"message"`
+}
+
+// ValidationReport lists all diagnostics; Valid is false when any of them is an error
+type ValidationReport struct {
+	Valid       bool         `json:"valid"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+func (r *ValidationReport) add(d Diagnostic) {
+	if d.Severity == SeverityError {
+		r.Valid = false
+	}
+	r.Diagnostics = append(r.Diagnostics, d)
+}
+
+// Validate checks a template without saving it. Unlike CreateTemplate it does not stop
+// at the first problem: every finding is reported with its location and code.
+func (s *TemplateService) Validate(template *model.Template) (*ValidationReport, error) {
+	report := &ValidationReport{Valid: true, Diagnostics: []Diagnostic{}}
+	if len(template.Blocks) == 0 {
+		report.add(Diagnostic{Severity: SeverityError, Code: DiagNoBlocks, Message: "template has no blocks"})
+	}
+
+	blocks, err := s.diagnoseBlockContents(template, report)
+	if err != nil {
+		return nil, err
+	}
+	diagnoseTimes(template.Blocks, blocks, report)
+
+	layout := *template
+	layout.Blocks = blocks
+	if err := validateLayout(&layout); err != nil {
+		report.add(Diagnostic{Severity: SeverityError, Code: DiagInvalidLayout, Message: err.Error()})
+	}
+	return report, nil
+}
+
+// diagnoseBlockContents resolves playlist references, checks that zone playlists exist
+// and checks every block's contents.
+// It returns the blocks with the contents they would play, leaving the template untouched.
+func (s *TemplateService) diagnoseBlockContents(template *model.Template, report *ValidationReport) ([]model.TemplateBlock, error) {
+	var playlistIDs, contentIDs []uint
+	for _, b := range template.Blocks {
+		if b.PlaylistID != nil {
+			playlistIDs = append(playlistIDs, *b.PlaylistID)
+		}
+		for _, c := range b.Contents {
+			contentIDs = append(contentIDs, c.ContentID)
+		}
+	}
+	for _, z := range template.Zones {
+		if z.PlaylistID != nil {
+			playlistIDs = append(playlistIDs, *z.PlaylistID)
+		}
+	}
+	playlists, err := s.playlistRepo.GetByIDs(playlistIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, z := range template.Zones {
+		if z.PlaylistID == nil {
+			continue
+		}
+		if _, ok := playlists[*z.PlaylistID]; !ok {
+			report.add(Diagnostic{Severity: SeverityError, Code: DiagPlaylistNotFound, Path: fmt.Sprintf("zones[%d]", i),
+				Message: fmt.Sprintf("zone %q: playlist %d not found", z.Name, *z.PlaylistID)})
+		}
+	}
+	for _, p := range playlists {
+		for _, it := range p.Items {
+			contentIDs = append(contentIDs, it.ContentID)
+		}
+	}
+	contents, err := s.contentRepo.GetByIDs(contentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]model.TemplateBlock, len(template.Blocks))
+	for i, b := range template.Blocks {
+		path := fmt.Sprintf("blocks[%d]", i)
+		mode := b.PlaylistMode
+		if err := checkPlaylistMode(b.Name, b.PlaylistID, &mode); err != nil {
+			report.add(Diagnostic{Severity: SeverityError, Code: DiagInvalidPlaylistMode, Path: path, Block: b.Name, Message: err.Error()})
+		}
+		if b.PlaylistID != nil {
+			playlist, ok := playlists[*b.PlaylistID]
+			switch {
+			case !ok:
+				report.add(Diagnostic{Severity: SeverityError, Code: DiagPlaylistNotFound, Path: path, Block: b.Name,
+					Message: fmt.Sprintf("block %q: playlist %d not found", b.Name, *b.PlaylistID)})
+			case mode == model.PlaylistLink, mode == model.PlaylistSnapshot && len(b.Contents) == 0:
+				if err := checkTemplatePlaylist(b.Name, &playlist); err != nil {
+					report.add(Diagnostic{Severity: SeverityError, Code: DiagPlaylistItemWindow, Path: path, Block: b.Name, Message: err.Error()})
+				}
+				b.Contents = repository.PlaylistTemplateContents(&playlist, b.ID)
+			}
+		}
+		blocks[i] = b
+
+		if len(b.Contents) == 0 {
+			report.add(Diagnostic{Severity: SeverityWarning, Code: DiagEmptyBlock, Path: path, Block: b.Name,
+				Message: fmt.Sprintf("block %q has no content; the default playlist plays instead", b.Name)})
+			continue
+		}
+		for j, c := range b.Contents {
+			diagnoseContent(b.Name, fmt.Sprintf("%s.contents[%d]", path, j), c, contents, report)
+		}
+		diagnoseLoopLength(b, path, contents, report)
+	}
+	return blocks, nil
+}
+
+func diagnoseContent(block, path string, c model.TemplateContent, contents map[uint]model.Content, report *ValidationReport) {
+	d := Diagnostic{Path: path, Block: block, ContentID: c.ContentID}
+	content, ok := contents[c.ContentID]
+	if !ok {
+		d.Severity, d.Code = SeverityError, DiagMissingContent
+		d.Message = fmt.Sprintf("block %q: content %d not found", block, c.ContentID)
+		report.add(d)
+		return
+	}
+	if w := reviewWarning(block, content); w != "" {
+		u := d
+		u.Severity, u.Code, u.Message = SeverityWarning, DiagUnapprovedContent, w
+		report.add(u)
+	}
+	if c.Duration > 0 {
+		return
+	}
+	switch {
+	case contentDuration(&content) == 0:
+		d.Severity, d.Code = SeverityError, DiagNoDuration
+		d.Message = fmt.Sprintf("block %q: %s %q has no duration", block, content.Type, content.Title)
+		report.add(d)
+	case content.Type == model.ContentTypeImage && content.EffectiveDuration() == 0:
+		d.Severity, d.Code = SeverityWarning, DiagImageWithoutDuration
+		d.Message = fmt.Sprintf("block %q: image %q has no duration and is shown for the default %d s",
+			block, content.Title, contentDuration(&content))
+		report.add(d)
+	}
+}
+
+// diagnoseLoopLength compares each zone's loop with the block's length: a longer loop
+// is cut off when the block ends, a shorter one repeats
+func diagnoseLoopLength(b model.TemplateBlock, path string, contents map[uint]model.Content, report *ValidationReport) {
+	start, end, ok := blockMinutes(b)
+	if !ok || end <= start {
+		return
+	}
+	length := (end - start) * 60
+
+	loops := make(map[string]int)
+	var zones []string
+	for _, c := range b.Contents {
+		content, ok := contents[c.ContentID]
+		if !ok {
+			continue
+		}
+		d := c.Duration
+		if d <= 0 {
+			d = contentDuration(&content)
+		}
+		if _, seen := loops[c.Zone]; !seen {
+			zones = append(zones, c.Zone)
+		}
+		loops[c.Zone] += d
+	}
+	for _, zone := range zones {
+		loop := loops[zone]
+		where := fmt.Sprintf("block %q", b.Name)
+		if zone != "" {
+			where += fmt.Sprintf(" zone %q", zone)
+		}
+		switch {
+		case loop > length:
+			report.add(Diagnostic{Severity: SeverityWarning, Code: DiagPlaylistTooLong, Path: path, Block: b.Name,
+				Message: fmt.Sprintf("%s: playlist runs %d s but the block lasts %d s; the rest is cut off", where, loop, length)})
+		case loop > 0 && loop < length:
+			report.add(Diagnostic{Severity: SeverityInfo, Code: DiagPlaylistTooShort, Path: path, Block: b.Name,
+				Message: fmt.Sprintf("%s: playlist runs %d s and repeats to fill the %d s block", where, loop, length)})
+		}
+	}
+}
+
+// diagnoseTimes reports blocks without times, overlapping blocks and hours of the day no block covers
+func diagnoseTimes(original, blocks []model.TemplateBlock, report *ValidationReport) {
+	for i, b := range original {
+		start, end, ok := blockMinutes(b)
+		if !ok {
+			report.add(Diagnostic{Severity: SeverityError, Code: DiagMissingTime, Path: fmt.Sprintf("blocks[%d]", i), Block: b.Name,
+				Message: fmt.Sprintf("block %q: start time or end time is empty", b.Name)})
+			continue
+		}
+		if end <= start {
+			report.add(Diagnostic{Severity: SeverityWarning, Code: DiagInvalidTimeRange, Path: fmt.Sprintf("blocks[%d]", i), Block: b.Name,
+				Message: fmt.Sprintf("block %q (%s) ends before it starts and never plays", b.Name, formatRange(start, end))})
+		}
+	}
+
+	ivs := blockIntervals(original)
+	overlappingIntervals(ivs, func(first, second blockInterval) {
+		a, b := blocks[first.index], blocks[second.index]
+		report.add(Diagnostic{Severity: SeverityError, Code: DiagOverlappingBlocks, Path: fmt.Sprintf("blocks[%d]", second.index), Block: b.Name,
+			Message: fmt.Sprintf("block %q (%s) overlaps block %q (%s)", b.Name, formatRange(second.start, second.end), a.Name, formatRange(first.start, first.end))})
+	})
+
+	covered := 0
+	for _, iv := range ivs {
+		if iv.start > covered {
+			reportUncovered(covered, iv.start, report)
+		}
+		if iv.end > covered {
+			covered = iv.end
+		}
+	}
+	if len(ivs) > 0 && covered < minutesPerDay {
+		reportUncovered(covered, minutesPerDay, report)
+	}
+}
+
+func reportUncovered(from, to int, report *ValidationReport) {
+	report.add(Diagnostic{Severity: SeverityWarning, Code: DiagUncoveredHours,
+		Message: fmt.Sprintf("no block covers %s; the default playlist plays", formatRange(from, to))})
+}
+
+// blockMinutes returns the block's time of day in minutes; an end of 00:00 means midnight
+func blockMinutes(b model.TemplateBlock) (start, end int, ok bool) {
+	if b.StartTime.IsZero() || b.EndTime.IsZero() {
+		return 0, 0, false
+	}
+	start = b.StartTime.Hour()*60 + b.StartTime.Minute()
+	end = b.EndTime.Hour()*60 + b.EndTime.Minute()
+	if end == 0 {
+		end = minutesPerDay
+	}
+	return start, end, true
+}
+
+// blockInterval is the time of day a block plays, in minutes: [start, end)
+type blockInterval struct {
+	index      int // position of the block in the template
+	start, end int
+}
+
+// blockIntervals returns the intervals of the blocks that play, sorted by start.
+// Blocks without times or ending before they start are skipped: they never play.
+func blockIntervals(blocks []model.TemplateBlock) []blockInterval {
+	var ivs []blockInterval
+	for i, b := range blocks {
+		if start, end, ok := blockMinutes(b); ok && end > start {
+			ivs = append(ivs, blockInterval{index: i, start: start, end: end})
+		}
+	}
+	sort.SliceStable(ivs, func(i, j int) bool { return ivs[i].start < ivs[j].start })
+	return ivs
+}
+
+// overlappingIntervals calls fn for every pair of intervals sharing at least a minute;
+// ivs must be sorted by start, first starts no later than second
+func overlappingIntervals(ivs []blockInterval, fn func(first, second blockInterval)) {
+	for i := range ivs {
+		for j := i + 1; j < len(ivs) && ivs[j].start < ivs[i].end; j++ {
+			fn(ivs[i], ivs[j])
+		}
+	}
+}
+
+func formatRange(from, to int) string {
+	return fmt.Sprintf("%02d:%02d–%02d:%02d", from/60, from%60, to/60, to%60)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+func TestBlockOverlapsAreConsistent(t *testing.T) {
+	block := func(name string, sh, sm, eh, em int) model.TemplateBlock {
+		return model.TemplateBlock{Name: name,
+			StartTime: time.Date(2026, 10, 16, sh, sm, 0, 0, time.UTC),
+			EndTime:   time.Date(2026, 10, 16, eh, em, 0, 0, time.UTC)}
+	}
+	tests := []struct {
+		name     string
+		blocks   []model.TemplateBlock
+		overlaps bool
+		invalid  int
+	}{
+		{"block until midnight contains a later block", []model.TemplateBlock{block("A", 22, 0, 0, 0), block("B", 23, 0, 23, 30)}, true, 0},
+		{"later block listed first", []model.TemplateBlock{block("B", 23, 0, 23, 30), block("A", 22, 0, 0, 0)}, true, 0},
+		{"adjacent at 22:00", []model.TemplateBlock{block("A", 20, 0, 22, 0), block("B", 22, 0, 0, 0)}, false, 0},
+		{"adjacent at midnight", []model.TemplateBlock{block("A", 22, 0, 0, 0), block("B", 0, 0, 6, 0)}, false, 0},
+		{"whole day from midnight", []model.TemplateBlock{block("A", 0, 0, 0, 0), block("B", 10, 0, 11, 0)}, true, 0},
+		{"overnight block never plays", []model.TemplateBlock{block("A", 22, 0, 2, 0), block("B", 1, 0, 3, 0)}, false, 1},
+		{"overnight block against a late block", []model.TemplateBlock{block("A", 22, 0, 2, 0), block("B", 23, 0, 23, 30)}, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overlapsTemplateBlocks(tt.blocks); got != tt.overlaps {
+				t.Fatalf("overlapsTemplateBlocks = %v, want %v", got, tt.overlaps)
+			}
+			report := &ValidationReport{Valid: true}
+			diagnoseTimes(tt.blocks, tt.blocks, report)
+			overlaps, invalid := false, 0
+			for _, d := range report.Diagnostics {
+				switch d.Code {
+				case DiagOverlappingBlocks:
+					overlaps = true
+				case DiagInvalidTimeRange:
+					invalid++
+				}
+			}
+			if overlaps != tt.overlaps || invalid != tt.invalid {
+				t.Fatalf("diagnostics = %+v", report.Diagnostics)
+			}
+		})
+	}
+}
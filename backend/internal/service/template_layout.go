@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+// validateLayout проверяет раскладку шаблона: области должны лежать внутри холста
+// и иметь уникальные имена, а контент блоков — ссылаться только на эти области.
+// Холст без размеров получает размер по умолчанию.
+func validateLayout(template *model.Template) error {
+	if template.CanvasWidth == 0 && template.CanvasHeight == 0 {
+		template.CanvasWidth, template.CanvasHeight = model.DefaultCanvasWidth, model.DefaultCanvasHeight
+	}
+	if template.CanvasWidth <= 0 || template.CanvasHeight <= 0 {
+		return fmt.Errorf("canvas size must be positive")
+	}
+
+	zones := make(map[string]bool, len(template.Zones))
+	for _, z := range template.Zones {
+		if z.Name == "" {
+			return fmt.Errorf("zone name is required")
+		}
+		if zones[z.Name] {
+			return fmt.Errorf("zone %q is defined twice", z.Name)
+		}
+		zones[z.Name] = true
+		if z.Width <= 0 || z.Height <= 0 {
+			return fmt.Errorf("zone %q: size must be positive", z.Name)
+		}
+		if z.X < 0 || z.Y < 0 || z.X+z.Width > template.CanvasWidth || z.Y+z.Height > template.CanvasHeight {
+			return fmt.Errorf("zone %q (%d,%d %dx%d) does not fit the %dx%d canvas",
+				z.Name, z.X, z.Y, z.Width, z.Height, template.CanvasWidth, template.CanvasHeight)
+		}
+	}
+
+	for _, b := range template.Blocks {
+		for _, c := range b.Contents {
+			if c.Zone != "" && !zones[c.Zone] {
+				return fmt.Errorf("block %q: unknown zone %q", b.Name, c.Zone)
+			}
+		}
+	}
+	return nil
+}
+
+// checkItemZones проверяет, что элементы блоков расписания ссылаются на области его шаблона
+func checkItemZones(schedule *model.Schedule, zones []model.TemplateZone) error {
+	known := make(map[string]bool, len(zones))
+	for _, z := range zones {
+		known[z.Name] = true
+	}
+	for _, b := range schedule.Blocks {
+		for _, item := range b.Items {
+			if item.Zone != "" && !known[item.Zone] {
+				return fmt.Errorf("block %q: unknown zone %q", b.Name, item.Zone)
+			}
+		}
+	}
+	return nil
+}
@@ -4,15 +4,21 @@ import (
 	"fmt"
 	"github.com/TryHanger/digital_signage/backend/internal/model"
 	"github.com/TryHanger/digital_signage/backend/internal/repository"
-	"sort"
 )
 
 type TemplateService struct {
-	repo *repository.TemplateRepository
+	repo         *repository.TemplateRepository
+	contentRepo  *repository.ContentRepository
+	playlistRepo *repository.PlaylistRepository
 }
 
-func NewTemplateService(repo *repository.TemplateRepository) *TemplateService {
-	return &TemplateService{repo: repo}
+func NewTemplateService(repo *repository.TemplateRepository, contentRepo *repository.ContentRepository, playlistRepo *repository.PlaylistRepository) *TemplateService {
+	return &TemplateService{repo: repo, contentRepo: contentRepo, playlistRepo: playlistRepo}
+}
+
+// withTx returns the service bound to the transaction of tx
+func (s *TemplateService) withTx(tx *repository.ContentRepository) *TemplateService {
+	return &TemplateService{repo: tx.Templates(), contentRepo: tx, playlistRepo: tx.Playlists()}
 }
 
 func (s *TemplateService) CreateTemplate(template *model.Template) error {
@@ -29,6 +35,9 @@ func (s *TemplateService) CreateTemplate(template *model.Template) error {
 	if overlapsTemplateBlocks(template.Blocks) {
 		return fmt.Errorf("template blocks have overlapping time ranges")
 	}
+	if err := s.resolveBlocks(template); err != nil {
+		return err
+	}
 	return s.repo.CreateTemplate(template)
 }
 
@@ -49,37 +58,201 @@ func (s *TemplateService) UpdateTemplate(template *model.Template) error {
 	if overlapsTemplateBlocks(template.Blocks) {
 		return fmt.Errorf("template blocks have overlapping time ranges")
 	}
+	if err := s.resolveBlocks(template); err != nil {
+		return err
+	}
 
-	return s.repo.UpdateTemplate(template)
+	// a save that changes nothing keeps the version: pinned schedules are not reported as outdated
+	return s.repo.UpdateTemplate(template, func(latest, saved *model.Template) bool {
+		return len(diffTemplates(latest, saved)) > 0
+	})
 }
 
-// overlapsTemplateBlocks checks whether any TemplateBlock time ranges overlap (by time-of-day)
+// overlapsTemplateBlocks checks whether any TemplateBlock time ranges overlap (by time-of-day);
+// it uses the same intervals as the overlapping_blocks diagnostic
 func overlapsTemplateBlocks(blocks []model.TemplateBlock) bool {
-	type interval struct{ start, end int }
-	var ivs []interval
-	for _, b := range blocks {
-		sh, sm := b.StartTime.Hour(), b.StartTime.Minute()
-		eh, em := b.EndTime.Hour(), b.EndTime.Minute()
-		start := sh*60 + sm
-		end := eh*60 + em
-		ivs = append(ivs, interval{start: start, end: end})
-	}
-	// sort by start
-	sort.Slice(ivs, func(i, j int) bool { return ivs[i].start < ivs[j].start })
-	for i := 0; i+1 < len(ivs); i++ {
-		if ivs[i].end > ivs[i+1].start { // overlap
-			return true
+	overlaps := false
+	overlappingIntervals(blockIntervals(blocks), func(_, _ blockInterval) { overlaps = true })
+	return overlaps
+}
+
+// resolveBlocks applies playlist references, resolves the blocks' contents and checks the layout.
+// Zone playlists only have to exist: the player falls back to them when a block leaves the zone empty.
+// Linked blocks are checked with the playlist items but store no contents: they are filled
+// from the playlist on every read. Snapshot blocks without contents get a copy of the playlist items.
+func (s *TemplateService) resolveBlocks(template *model.Template) error {
+	var ids []uint
+	for i := range template.Blocks {
+		b := &template.Blocks[i]
+		if err := checkPlaylistMode(b.Name, b.PlaylistID, &b.PlaylistMode); err != nil {
+			return err
+		}
+		if b.PlaylistID != nil {
+			ids = append(ids, *b.PlaylistID)
+		}
+	}
+	for _, z := range template.Zones {
+		if z.PlaylistID != nil {
+			ids = append(ids, *z.PlaylistID)
+		}
+	}
+	playlists, err := playlistsFor(s.playlistRepo, ids)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range template.Blocks {
+			if template.Blocks[i].PlaylistMode == model.PlaylistLink {
+				template.Blocks[i].Contents = nil
+			}
+		}
+	}()
+
+	for i := range template.Blocks {
+		b := &template.Blocks[i]
+		if b.PlaylistID == nil || (b.PlaylistMode == model.PlaylistSnapshot && len(b.Contents) > 0) {
+			continue
+		}
+		playlist := playlists[*b.PlaylistID]
+		if err := checkTemplatePlaylist(b.Name, &playlist); err != nil {
+			return err
+		}
+		b.Contents = repository.PlaylistTemplateContents(&playlist, b.ID)
+	}
+	return s.checkBlocks(template)
+}
+
+// checkPlaylist checks that the template stays valid once its blocks linked
+// to the playlist get the playlist's items
+func (s *TemplateService) checkPlaylist(template *model.Template, playlist *model.Playlist) error {
+	for i := range template.Blocks {
+		b := &template.Blocks[i]
+		if b.PlaylistMode != model.PlaylistLink || b.PlaylistID == nil || *b.PlaylistID != playlist.ID {
+			continue
+		}
+		if err := checkTemplatePlaylist(b.Name, playlist); err != nil {
+			return err
+		}
+		b.Contents = repository.PlaylistTemplateContents(playlist, b.ID)
+	}
+	return s.checkBlocks(template)
+}
+
+// checkBlocks resolves the blocks' contents, playlist items included, and checks the layout
+func (s *TemplateService) checkBlocks(template *model.Template) error {
+	if err := s.resolveContents(template); err != nil {
+		return err
+	}
+	return validateLayout(template)
+}
+
+// checkTemplatePlaylist rejects playlists whose items have validity windows:
+// template contents have no windows, so such items would play around the clock
+func checkTemplatePlaylist(blockName string, playlist *model.Playlist) error {
+	for i, it := range playlist.Items {
+		if it.HasWindow() {
+			return fmt.Errorf("block %q: playlist %q item %d has a validity window, which only schedule blocks support",
+				blockName, playlist.Name, i+1)
+		}
+	}
+	return nil
+}
+
+// resolveContents copies each referenced content's type into TemplateContent.Type,
+// checks that contents without an explicit duration have one of their own
+// and collects warnings about contents that are not approved yet
+func (s *TemplateService) resolveContents(template *model.Template) error {
+	var ids []uint
+	for _, b := range template.Blocks {
+		for _, c := range b.Contents {
+			ids = append(ids, c.ContentID)
+		}
+	}
+	contents, err := s.contentRepo.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	template.Warnings = nil
+	for _, b := range template.Blocks {
+		for j := range b.Contents {
+			c := &b.Contents[j]
+			content, ok := contents[c.ContentID]
+			if !ok {
+				return fmt.Errorf("block %q: content %d not found", b.Name, c.ContentID)
+			}
+			c.Type = content.Type
+			if w := reviewWarning(b.Name, content); w != "" {
+				template.Warnings = append(template.Warnings, w)
+			}
+			if c.Duration > 0 {
+				continue
+			}
+			if err := checkContentDuration(contents, c.ContentID); err != nil {
+				return fmt.Errorf("block %q: %w", b.Name, err)
+			}
 		}
 	}
-	return false
+	return nil
 }
 
 func (s *TemplateService) GetAll() ([]model.Template, error) {
-	return s.repo.GetAll()
+	templates, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	ptrs := make([]*model.Template, len(templates))
+	for i := range templates {
+		ptrs[i] = &templates[i]
+	}
+	if err := s.markUnapproved(ptrs...); err != nil {
+		return nil, err
+	}
+	return templates, nil
 }
 
 func (s *TemplateService) GetByID(id uint) (*model.Template, error) {
-	return s.repo.GetByID(id)
+	template, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.markUnapproved(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// markUnapproved fills Warnings with a reviewWarning for every block content that
+// is not approved yet. Contents of all templates are loaded in one query.
+func (s *TemplateService) markUnapproved(templates ...*model.Template) error {
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, template := range templates {
+		for _, b := range template.Blocks {
+			for _, c := range b.Contents {
+				if !seen[c.ContentID] {
+					seen[c.ContentID] = true
+					ids = append(ids, c.ContentID)
+				}
+			}
+		}
+	}
+	contents, err := s.contentRepo.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	for _, template := range templates {
+		template.Warnings = nil
+		for _, b := range template.Blocks {
+			for _, c := range b.Contents {
+				if content, ok := contents[c.ContentID]; ok {
+					if w := reviewWarning(b.Name, content); w != "" {
+						template.Warnings = append(template.Warnings, w)
+					}
+				}
+			}
+		}
+	}
+	return nil
 }
 
 func (s *TemplateService) Delete(id uint) error {
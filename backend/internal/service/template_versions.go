@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTemplateNotFound        = errors.New("template not found")
+	ErrTemplateVersionNotFound = errors.New("template version not found")
+)
+
+// Template change kinds
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// TemplateChange is a difference between two template versions.
+// Path names the changed part: "name", "canvas", "zones.<name>", "blocks.<id>.startTime", ...
+type TemplateChange struct {
+	Path string      `json:"path"`
+	Kind string      `json:"kind"` // added | removed | changed
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// versionContent is a block content as compared between versions (row ids are not stable)
+type versionContent struct {
+	ContentID uint   `json:"contentId"`
+	Duration  int    `json:"duration,omitempty"`
+	Zone      string `json:"zone,omitempty"`
+}
+
+// Versions returns the template's versions without snapshots, newest first
+func (s *TemplateService) Versions(id uint) ([]model.TemplateVersion, error) {
+	if err := s.checkExists(id); err != nil {
+		return nil, err
+	}
+	return s.repo.Versions(id)
+}
+
+// GetVersion returns a version with its snapshot
+func (s *TemplateService) GetVersion(id uint, version int) (*model.TemplateVersion, error) {
+	v, err := s.repo.GetVersion(id, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTemplateVersionNotFound
+	}
+	return v, err
+}
+
+// DiffVersions compares the snapshots of two template versions
+func (s *TemplateService) DiffVersions(id uint, from, to int) ([]TemplateChange, error) {
+	a, err := s.versionSnapshot(id, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.versionSnapshot(id, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffTemplates(a, b), nil
+}
+
+// OutdatedSchedules returns schedules created from an older version of the template
+func (s *TemplateService) OutdatedSchedules(id uint) ([]repository.OutdatedSchedule, error) {
+	if err := s.checkExists(id); err != nil {
+		return nil, err
+	}
+	return s.repo.OutdatedSchedules(id)
+}
+
+func (s *TemplateService) checkExists(id uint) error {
+	_, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTemplateNotFound
+	}
+	return err
+}
+
+func (s *TemplateService) versionSnapshot(id uint, version int) (*model.Template, error) {
+	v, err := s.GetVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+	var template model.Template
+	if err := json.Unmarshal(v.Snapshot, &template); err != nil {
+		return nil, fmt.Errorf("template %d version %d: %w", id, version, err)
+	}
+	return &template, nil
+}
+
+// diffTemplates lists changes from a to b: template fields, zones by name and blocks by id
+func diffTemplates(a, b *model.Template) []TemplateChange {
+	changes := []TemplateChange{}
+	changed := func(path string, x, y interface{}) {
+		if !reflect.DeepEqual(x, y) {
+			changes = append(changes, TemplateChange{Path: path, Kind: ChangeChanged, From: x, To: y})
+		}
+	}
+	changed("name", a.Name, b.Name)
+	changed("description", a.Description, b.Description)
+	changed("canvas", fmt.Sprintf("%dx%d", a.CanvasWidth, a.CanvasHeight), fmt.Sprintf("%dx%d", b.CanvasWidth, b.CanvasHeight))
+
+	zonesA := make(map[string]model.TemplateZone, len(a.Zones))
+	for _, z := range a.Zones {
+		z.ID, z.TemplateID = 0, 0
+		zonesA[z.Name] = z
+	}
+	zonesB := make(map[string]bool, len(b.Zones))
+	for _, z := range b.Zones {
+		z.ID, z.TemplateID = 0, 0
+		zonesB[z.Name] = true
+		path := "zones." + z.Name
+		if old, ok := zonesA[z.Name]; ok {
+			changed(path, old, z)
+		} else {
+			changes = append(changes, TemplateChange{Path: path, Kind: ChangeAdded, To: z})
+		}
+	}
+	for _, z := range a.Zones {
+		if !zonesB[z.Name] {
+			z.ID, z.TemplateID = 0, 0
+			changes = append(changes, TemplateChange{Path: "zones." + z.Name, Kind: ChangeRemoved, From: z})
+		}
+	}
+
+	blocksA := make(map[uint]model.TemplateBlock, len(a.Blocks))
+	for _, bl := range a.Blocks {
+		blocksA[bl.ID] = bl
+	}
+	blocksB := make(map[uint]bool, len(b.Blocks))
+	for _, bl := range b.Blocks {
+		blocksB[bl.ID] = true
+		path := fmt.Sprintf("blocks.%d", bl.ID)
+		old, ok := blocksA[bl.ID]
+		if !ok {
+			changes = append(changes, TemplateChange{Path: path, Kind: ChangeAdded, To: blockSummary(bl)})
+			continue
+		}
+		changed(path+".name", old.Name, bl.Name)
+		changed(path+".startTime", old.StartTime.Format("15:04"), bl.StartTime.Format("15:04"))
+		changed(path+".endTime", old.EndTime.Format("15:04"), bl.EndTime.Format("15:04"))
+		changed(path+".playlist", blockPlaylist(old), blockPlaylist(bl))
+		changed(path+".contents", versionContents(old), versionContents(bl))
+	}
+	for _, bl := range a.Blocks {
+		if !blocksB[bl.ID] {
+			changes = append(changes, TemplateChange{Path: fmt.Sprintf("blocks.%d", bl.ID), Kind: ChangeRemoved, From: blockSummary(bl)})
+		}
+	}
+	return changes
+}
+
+func blockSummary(b model.TemplateBlock) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      b.Name,
+		"startTime": b.StartTime.Format("15:04"),
+		"endTime":   b.EndTime.Format("15:04"),
+		"playlist":  blockPlaylist(b),
+		"contents":  versionContents(b),
+	}
+}
+
+// blockPlaylist describes the block's playlist reference as "<id>/<mode>"; empty without one
+func blockPlaylist(b model.TemplateBlock) string {
+	if b.PlaylistID == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%s", *b.PlaylistID, b.PlaylistMode)
+}
+
+// versionContents lists the block's contents in playback order
+func versionContents(b model.TemplateBlock) []versionContent {
+	ordered := append([]model.TemplateContent(nil), b.Contents...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Order < ordered[j].Order })
+	contents := make([]versionContent, 0, len(ordered))
+	for _, c := range ordered {
+		contents = append(contents, versionContent{ContentID: c.ContentID, Duration: c.Duration, Zone: c.Zone})
+	}
+	return contents
+}
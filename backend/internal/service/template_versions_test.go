@@ -0,0 +1,42 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+func TestDiffTemplatesIgnoresResave(t *testing.T) {
+	start := time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)
+	saved := model.Template{
+		ID: 1, Name: "Утро", Version: 4, CanvasWidth: 1920, CanvasHeight: 1080,
+		Zones: []model.TemplateZone{{ID: 7, TemplateID: 1, Name: "promo", Width: 480, Height: 1080}},
+		Blocks: []model.TemplateBlock{{ID: 2, Name: "Утро", StartTime: start, EndTime: start.Add(4 * time.Hour),
+			Contents: []model.TemplateContent{{ID: 5, BlockID: 2, ContentID: 9, Order: 1, Duration: 10, Zone: "promo"}}}},
+		UpdatedAt: time.Now(),
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var latest model.Template
+	if err := json.Unmarshal(data, &latest); err != nil {
+		t.Fatal(err)
+	}
+
+	// повторное сохранение пересоздаёт области и строки контента с новыми ID
+	saved.Zones[0].ID = 8
+	saved.Blocks[0].Contents[0].ID = 6
+	saved.UpdatedAt = saved.UpdatedAt.Add(time.Minute)
+	if changes := diffTemplates(&latest, &saved); len(changes) != 0 {
+		t.Fatalf("changes = %+v, want none", changes)
+	}
+
+	playlist := uint(3)
+	saved.Zones[0].PlaylistID = &playlist
+	if changes := diffTemplates(&latest, &saved); len(changes) != 1 || changes[0].Path != "zones.promo" {
+		t.Fatalf("changes = %+v, want zones.promo", changes)
+	}
+}
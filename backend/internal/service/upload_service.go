@@ -0,0 +1,298 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/TryHanger/digital_signage/backend/internal/repository"
+	"github.com/TryHanger/digital_signage/backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrOffsetMismatch    = errors.New("upload offset does not match")
+	ErrChecksumMismatch  = errors.New("checksum mismatch")
+	ErrUploadTooLarge    = errors.New("chunk exceeds upload length")
+	ErrUploadFinished    = errors.New("upload is already finished")
+	ErrChecksumAlgorithm = errors.New("unsupported checksum algorithm")
+)
+
+// UploadService реализует возобновляемые загрузки по протоколу tus:
+// создание, дозапись по смещению, статус и удаление, а также очистку брошенных загрузок
+type UploadService struct {
+	repo     *repository.UploadRepository
+	contents *ContentService
+	dir      string
+	ttl      time.Duration
+	locks    keyLocks
+}
+
+func NewUploadService(repo *repository.UploadRepository, contents *ContentService, dir string, ttl time.Duration) (*UploadService, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &UploadService{repo: repo, contents: contents, dir: dir, ttl: ttl}, nil
+}
+
+// ChunkChecksum — контрольная сумма одной части (заголовок Upload-Checksum)
+type ChunkChecksum struct {
+	Algorithm string
+	Sum       []byte
+}
+
+// MaxSize — максимальный размер загрузки (Tus-Max-Size)
+func (s *UploadService) MaxSize() int64 {
+	return s.contents.MaxUploadSize()
+}
+
+// Create регистрирует новую загрузку длиной length байт от пользователя uploadedBy
+func (s *UploadService) Create(length int64, metadata map[string]string, uploadedBy string) (*model.Upload, error) {
+	if length <= 0 {
+		return nil, errors.New("upload length must be positive")
+	}
+	if length > s.MaxSize() {
+		return nil, ErrFileTooLarge
+	}
+
+	upload := &model.Upload{
+		ID:          utils.GenerateShortToken() + utils.GenerateShortToken(),
+		Length:      length,
+		Filename:    metadata["filename"],
+		Title:       metadata["title"],
+		Description: metadata["description"],
+		Checksum:    strings.ToLower(metadata["checksum"]),
+		UploadedBy:  uploadedBy,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	if upload.Title == "" {
+		upload.Title = upload.Filename
+	}
+	if d := metadata["duration"]; d != "" {
+		if _, err := fmt.Sscan(d, &upload.Duration); err != nil {
+			return nil, fmt.Errorf("invalid duration %q", d)
+		}
+	}
+	if v := metadata["locationId"]; v != "" {
+		var locationID uint
+		if _, err := fmt.Sscan(v, &locationID); err != nil {
+			return nil, fmt.Errorf("invalid locationId %q", v)
+		}
+		upload.LocationID = &locationID
+	}
+	// заведомо не помещающийся файл отклоняется сразу, а не после передачи всех байт
+	if s.contents.storage != nil {
+		if err := s.contents.storage.CheckQuota(upload.LocationID, &StoredFile{Checksum: upload.Checksum, Size: length}); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Create(s.partPath(upload.ID))
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	if err := s.repo.Create(upload); err != nil {
+		os.Remove(s.partPath(upload.ID))
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (s *UploadService) Get(id string) (*model.Upload, error) {
+	upload, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	return upload, err
+}
+
+// Append дописывает часть r начиная со смещения offset. Когда получены все байты,
+// сверяет SHA-256 и создаёт запись Content.
+func (s *UploadService) Append(id string, offset int64, r io.Reader, checksum *ChunkChecksum) (*model.Upload, error) {
+	s.locks.lock(id)
+	defer s.locks.unlock(id)
+
+	upload, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.ContentID != nil {
+		return nil, ErrUploadFinished
+	}
+	if offset != upload.Offset {
+		return nil, ErrOffsetMismatch
+	}
+
+	var chunkHash hash.Hash
+	if checksum != nil {
+		if checksum.Algorithm != "sha256" {
+			return nil, ErrChecksumAlgorithm
+		}
+		chunkHash = sha256.New()
+	}
+
+	f, err := os.OpenFile(s.partPath(id), os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(upload.Offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	remaining := upload.Length - upload.Offset
+	var w io.Writer = f
+	if chunkHash != nil {
+		w = io.MultiWriter(f, chunkHash)
+	}
+	// читаем на байт больше остатка, чтобы заметить переполнение
+	written, copyErr := io.Copy(w, io.LimitReader(r, remaining+1))
+	if written > remaining {
+		copyErr = ErrUploadTooLarge
+	}
+	if copyErr == nil && chunkHash != nil && !bytes.Equal(chunkHash.Sum(nil), checksum.Sum) {
+		copyErr = ErrChecksumMismatch
+	}
+	if copyErr == ErrUploadTooLarge || copyErr == ErrChecksumMismatch {
+		// часть отклонена целиком — откатываем файл к прежнему смещению
+		written = 0
+		if err := f.Truncate(upload.Offset); err != nil {
+			copyErr = err
+		}
+	}
+	if err := f.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+
+	// сохраняем принятые байты даже при обрыве соединения — клиент продолжит с нового смещения
+	upload.Offset += written
+	upload.ExpiresAt = time.Now().Add(s.ttl)
+	if err := s.repo.Update(upload); err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return upload, copyErr
+	}
+
+	if upload.Offset == upload.Length {
+		if err := s.finish(upload); err != nil {
+			return upload, err
+		}
+	}
+	return upload, nil
+}
+
+// finish сверяет контрольную сумму и создаёт запись контента
+func (s *UploadService) finish(upload *model.Upload) error {
+	f, err := os.Open(s.partPath(upload.ID))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stored, err := s.contents.StoreLocalFile(f)
+	if err != nil {
+		return err
+	}
+	if upload.Checksum != "" && upload.Checksum != stored.Checksum {
+		// данные повреждены: загрузку придётся начать заново, а уже сохранённый
+		// файл удаляем, если на него не ссылается другой контент
+		s.contents.releaseObjects(stored.Checksum, stored.Thumbnail)
+		s.remove(upload.ID)
+		return ErrChecksumMismatch
+	}
+
+	content := &model.Content{Title: upload.Title, Description: upload.Description, Duration: upload.Duration, LocationID: upload.LocationID}
+	if err := s.contents.CreateUploaded(content, stored, upload.UploadedBy); err != nil {
+		return err
+	}
+	upload.ContentID = &content.ID
+	if err := s.repo.Update(upload); err != nil {
+		return err
+	}
+	os.Remove(s.partPath(upload.ID))
+	return nil
+}
+
+// Delete прерывает загрузку (tus termination)
+func (s *UploadService) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	s.locks.lock(id)
+	defer s.locks.unlock(id)
+	return s.remove(id)
+}
+
+func (s *UploadService) remove(id string) error {
+	if err := os.Remove(s.partPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// StartCleanup периодически удаляет загрузки, к которым не обращались дольше TTL
+func (s *UploadService) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.CleanupExpired()
+		}
+	}()
+}
+
+func (s *UploadService) CleanupExpired() {
+	now := time.Now()
+	expired, err := s.repo.GetExpired(now)
+	if err != nil {
+		log.Printf("❌ Ошибка поиска просроченных загрузок: %v", err)
+		return
+	}
+	for _, upload := range expired {
+		removed, err := s.removeExpired(upload.ID, now)
+		if err != nil {
+			log.Printf("❌ Не удалось удалить загрузку %s: %v", upload.ID, err)
+			continue
+		}
+		if removed != nil {
+			log.Printf("🧹 Удалена просроченная загрузка %s (%d/%d байт)", removed.ID, removed.Offset, removed.Length)
+		}
+	}
+}
+
+// removeExpired удаляет загрузку id, если она всё ещё просрочена. Загрузка перечитывается
+// под блокировкой: пока шла очистка, клиент мог дописать часть и продлить срок.
+// Возвращает nil, если удалять не пришлось.
+func (s *UploadService) removeExpired(id string, now time.Time) (*model.Upload, error) {
+	s.locks.lock(id)
+	defer s.locks.unlock(id)
+	upload, err := s.Get(id)
+	if errors.Is(err, ErrUploadNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !upload.ExpiresAt.Before(now) {
+		return nil, nil
+	}
+	return upload, s.remove(id)
+}
+
+func (s *UploadService) partPath(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".part")
+}
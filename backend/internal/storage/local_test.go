@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+	s, err := NewLocal(t.TempDir(), "http://signage.local/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLocalPutGetStat(t *testing.T) {
+	s := newTestLocal(t)
+	if err := s.Put("ab/cd/file", bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := s.Stat("ab/cd/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Key != "ab/cd/file" || info.Size != int64(len(pngHeader)) || info.ModTime.IsZero() {
+		t.Fatalf("info = %+v", info)
+	}
+	if info.ContentType != "image/png" {
+		t.Fatalf("content type = %q, want image/png", info.ContentType)
+	}
+
+	r, err := s.Get("ab/cd/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := r.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pngHeader[4:]) {
+		t.Fatalf("read %q, want %q", got, pngHeader[4:])
+	}
+}
+
+func TestLocalNotFound(t *testing.T) {
+	s := newTestLocal(t)
+	if _, err := s.Stat("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete("missing"); err != nil {
+		t.Fatalf("Delete err = %v, want nil", err)
+	}
+	if ok, err := Exists(s, "missing"); ok || err != nil {
+		t.Fatalf("Exists = %v, %v", ok, err)
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	s := newTestLocal(t)
+	for _, key := range []string{"", "/", "../escape", "a/../../escape"} {
+		if err := s.Put(key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Fatalf("Put(%q) succeeded", key)
+		}
+	}
+}
+
+func TestLocalDelete(t *testing.T) {
+	s := newTestLocal(t)
+	if err := s.Put("key", strings.NewReader("data"), 4, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Exists(s, "key"); ok || err != nil {
+		t.Fatalf("Exists after delete = %v, %v", ok, err)
+	}
+}
+
+func TestLocalList(t *testing.T) {
+	s := newTestLocal(t)
+	for _, key := range []string{"thumbs/a.jpg", "thumbs/b.jpg", "ab/content"} {
+		if err := s.Put(key, strings.NewReader("plain text"), 10, "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// недописанный Put не должен попадать в список
+	if err := os.WriteFile(filepath.Join(s.root, "thumbs", ".put-123"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	err := s.List("thumbs/", func(info ObjectInfo) error {
+		if info.ContentType == "" {
+			t.Errorf("%s: empty content type", info.Key)
+		}
+		keys = append(keys, info.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "thumbs/a.jpg,thumbs/b.jpg" {
+		t.Fatalf("keys = %v", keys)
+	}
+}
+
+func TestLocalPresign(t *testing.T) {
+	s := newTestLocal(t)
+	link, err := s.Presign("ab/file", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != "signage.local" || u.Path != "/api/v1/media/ab/file" {
+		t.Fatalf("link = %s", link)
+	}
+	q := u.Query()
+	if !VerifySignature("secret", "ab/file", q.Get("expires"), q.Get("signature")) {
+		t.Fatal("signature does not verify")
+	}
+	if VerifySignature("secret", "ab/other", q.Get("expires"), q.Get("signature")) {
+		t.Fatal("signature verifies for another key")
+	}
+	if VerifySignature("secret", "ab/file", "1", SignKey("secret", "ab/file", 1)) {
+		t.Fatal("expired signature verifies")
+	}
+
+	s.secret = ""
+	if _, err := s.Presign("ab/file", time.Minute); err == nil {
+		t.Fatal("Presign without secret succeeded")
+	}
+}
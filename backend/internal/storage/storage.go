@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/config"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// ObjectInfo — сведения об объекте в хранилище
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage — бэкенд для медиафайлов (локальный диск, S3-совместимое хранилище)
+type Storage interface {
+	// Put сохраняет объект; size — точный размер содержимого r
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get открывает объект на чтение с возможностью Seek (для Range-запросов)
+	Get(key string) (io.ReadSeekCloser, error)
+	Stat(key string) (*ObjectInfo, error)
+	Delete(key string) error
+	// Presign возвращает временную ссылку на скачивание объекта
+	Presign(key string, ttl time.Duration) (string, error)
+	// List обходит все объекты с префиксом prefix
+	List(prefix string, fn func(ObjectInfo) error) error
+}
+
+// Драйверы хранилища
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// New создаёт хранилище выбранного драйвера по настройкам из config
+func New(cfg *config.Config, driver string) (Storage, error) {
+	switch driver {
+	case DriverLocal, "":
+		return NewLocal(cfg.StorageDir, cfg.PublicURL, cfg.PresignSecret)
+	case DriverS3:
+		return NewS3(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// Exists проверяет наличие объекта
+func Exists(s Storage, key string) (bool, error) {
+	_, err := s.Stat(key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Copy переносит объект key из src в dst
+func Copy(src, dst Storage, info ObjectInfo) error {
+	r, err := src.Get(info.Key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return dst.Put(info.Key, r, info.Size, info.ContentType)
+}
+
+// SignKey подписывает ключ и срок действия ссылки секретом (для локального Presign)
+func SignKey(secret, key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature проверяет подпись и срок действия ссылки, выданной Local.Presign
+func VerifySignature(secret, key, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(SignKey(secret, key, exp)), []byte(signature))
+}
@@ -1 +1,154 @@
 package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+)
+
+// ParseClock переводит строку "HH:MM" в минуты от начала суток
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// IsActiveOn проверяет, действует ли расписание в указанный день
+// (период, тип повторения, дни недели и исключения)
+func IsActiveOn(schedule model.Schedule, day time.Time) bool {
+	if !schedule.IsActive {
+		return false
+	}
+	d := dateOnly(day)
+	if d.Before(dateOnly(schedule.StartDate.In(day.Location()))) {
+		return false
+	}
+	if schedule.EndDate != nil && d.After(dateOnly(schedule.EndDate.In(day.Location()))) {
+		return false
+	}
+	for _, ex := range schedule.Exceptions {
+		if dateOnly(ex.Date).Equal(d) {
+			return false
+		}
+	}
+
+	switch schedule.RepeatType {
+	case model.RepeatNone:
+		return d.Equal(dateOnly(schedule.StartDate.In(day.Location())))
+	case model.RepeatWeekly:
+		wd := isoWeekday(day)
+		for _, w := range schedule.Weekdays {
+			if int(w) == wd {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// IsBlockActiveAt проверяет, попадает ли момент t во временное окно блока.
+// Окно может переходить через полночь ("22:00" - "06:00").
+func IsBlockActiveAt(block model.ScheduleBlock, t time.Time) bool {
+	return inClockRange(block.StartTime, block.EndTime, t)
+}
+
+// BlockStartDay возвращает момент t, перенесённый на день, в который началось окно блока:
+// для части окна после полуночи ("22:00" - "06:00" в 02:00) это предыдущий день.
+// По этому дню проверяются правила расписания (дни недели, период, исключения).
+func BlockStartDay(block model.ScheduleBlock, t time.Time) time.Time {
+	from, err := ParseClock(block.StartTime)
+	if err != nil {
+		return t
+	}
+	to, err := ParseClock(block.EndTime)
+	if err != nil || from < to {
+		return t
+	}
+	if now := t.Hour()*60 + t.Minute(); now < from && now < to {
+		return t.AddDate(0, 0, -1)
+	}
+	return t
+}
+
+// IsContentValidAt проверяет, что день t входит в период показа контента
+func IsContentValidAt(content model.Content, t time.Time) bool {
+	d := dateOnly(t)
+	if content.ValidFrom != nil && d.Before(dateOnly(*content.ValidFrom)) {
+		return false
+	}
+	return content.ValidUntil == nil || !d.After(dateOnly(*content.ValidUntil))
+}
+
+// IsItemActiveAt проверяет окно действия элемента блока: даты, дни недели и время суток,
+// а также период показа самого контента, если он загружен.
+// Даты и дни недели сверяются с днём начала блока day (см. BlockStartDay), время суток — с t.
+func IsItemActiveAt(item model.ScheduleBlockItem, day, t time.Time) bool {
+	if item.Content != nil && !IsContentValidAt(*item.Content, t) {
+		return false
+	}
+	d := dateOnly(day)
+	if item.ValidFrom != nil && d.Before(dateOnly(*item.ValidFrom)) {
+		return false
+	}
+	if item.ValidUntil != nil && d.After(dateOnly(*item.ValidUntil)) {
+		return false
+	}
+	if len(item.Weekdays) > 0 {
+		wd, found := isoWeekday(day), false
+		for _, w := range item.Weekdays {
+			if int(w) == wd {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if item.StartTime != "" || item.EndTime != "" {
+		return inClockRange(clockOr(item.StartTime, "00:00"), clockOr(item.EndTime, "00:00"), t)
+	}
+	return true
+}
+
+func clockOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func inClockRange(start, end string, t time.Time) bool {
+	from, err := ParseClock(start)
+	if err != nil {
+		return false
+	}
+	to, err := ParseClock(end)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if from < to {
+		return now >= from && now < to
+	}
+	// через полночь (или круглосуточно, если from == to)
+	return now >= from || now < to
+}
+
+// isoWeekday возвращает день недели в нумерации model.Monday..model.Sunday
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return model.Sunday
+	}
+	return int(t.Weekday())
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
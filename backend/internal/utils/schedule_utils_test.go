@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TryHanger/digital_signage/backend/internal/model"
+	"github.com/lib/pq"
+)
+
+// 2026-10-16 — пятница
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestBlockStartDay(t *testing.T) {
+	night := model.ScheduleBlock{StartTime: "22:00", EndTime: "06:00"}
+	allDay := model.ScheduleBlock{StartTime: "08:00", EndTime: "08:00"}
+	day := model.ScheduleBlock{StartTime: "08:00", EndTime: "20:00"}
+	tests := []struct {
+		name  string
+		block model.ScheduleBlock
+		t     time.Time
+		want  time.Time
+	}{
+		{"night block before midnight", night, at(16, 23, 0), at(16, 23, 0)},
+		{"night block after midnight", night, at(17, 2, 0), at(16, 2, 0)},
+		{"day block", day, at(16, 9, 0), at(16, 9, 0)},
+		{"round-the-clock block before its start", allDay, at(17, 7, 0), at(16, 7, 0)},
+		{"round-the-clock block after its start", allDay, at(17, 9, 0), at(17, 9, 0)},
+		{"invalid clock", model.ScheduleBlock{StartTime: "x", EndTime: "06:00"}, at(17, 2, 0), at(17, 2, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BlockStartDay(tt.block, tt.t); !got.Equal(tt.want) {
+				t.Fatalf("BlockStartDay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsActiveOnWeekly(t *testing.T) {
+	schedule := model.Schedule{
+		IsActive:   true,
+		StartDate:  at(1, 0, 0),
+		RepeatType: model.RepeatWeekly,
+		Weekdays:   pq.Int64Array{model.Friday},
+	}
+	night := model.ScheduleBlock{StartTime: "22:00", EndTime: "06:00"}
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"friday night", at(16, 23, 0), true},
+		{"saturday morning continues friday night", at(17, 2, 0), true},
+		{"friday morning belongs to thursday night", at(16, 2, 0), false},
+		{"saturday night", at(17, 23, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active := IsBlockActiveAt(night, tt.t) && IsActiveOn(schedule, BlockStartDay(night, tt.t))
+			if active != tt.want {
+				t.Fatalf("active = %v, want %v", active, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsItemActiveAt(t *testing.T) {
+	from, until := at(10, 0, 0), at(20, 0, 0)
+	tests := []struct {
+		name string
+		item model.ScheduleBlockItem
+		t    time.Time
+		want bool
+	}{
+		{"no window", model.ScheduleBlockItem{}, at(16, 12, 0), true},
+		{"inside dates", model.ScheduleBlockItem{ValidFrom: &from, ValidUntil: &until}, at(20, 23, 0), true},
+		{"after dates", model.ScheduleBlockItem{ValidFrom: &from, ValidUntil: &until}, at(21, 12, 0), false},
+		{"weekday matches", model.ScheduleBlockItem{Weekdays: pq.Int64Array{model.Friday}}, at(16, 12, 0), true},
+		{"weekday differs", model.ScheduleBlockItem{Weekdays: pq.Int64Array{model.Monday}}, at(16, 12, 0), false},
+		{"inside time of day", model.ScheduleBlockItem{StartTime: "10:00", EndTime: "14:00"}, at(16, 12, 0), true},
+		{"outside time of day", model.ScheduleBlockItem{StartTime: "10:00", EndTime: "14:00"}, at(16, 14, 0), false},
+		{"only start time", model.ScheduleBlockItem{StartTime: "10:00"}, at(16, 23, 59), true},
+		{"friday item after midnight of friday night", model.ScheduleBlockItem{Weekdays: pq.Int64Array{model.Friday}}, at(17, 2, 0), true},
+		{"saturday item after midnight of friday night", model.ScheduleBlockItem{Weekdays: pq.Int64Array{model.Saturday}}, at(17, 2, 0), false},
+		{"last date continues after midnight", model.ScheduleBlockItem{ValidUntil: &until}, at(21, 2, 0), true},
+		{"first date starts with the block", model.ScheduleBlockItem{ValidFrom: &from}, at(10, 2, 0), false},
+	}
+	night := model.ScheduleBlock{StartTime: "22:00", EndTime: "06:00"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsItemActiveAt(tt.item, BlockStartDay(night, tt.t), tt.t); got != tt.want {
+				t.Fatalf("IsItemActiveAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
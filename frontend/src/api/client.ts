@@ -14,6 +14,7 @@ const api = axios.create({
 export type Location = {
   id?: number
   name: string
+  storageQuota?: number // байты; 0 — квота по умолчанию
 }
 
 export type Monitor = {
@@ -21,6 +22,8 @@ export type Monitor = {
   name: string
   token?: string
   status?: string
+  width?: number // заявленное разрешение экрана
+  height?: number
   locationID?: number
   location?: Location
   groupID?: number
@@ -41,10 +44,95 @@ export type Content = {
   path: string
   description?: string
   duration?: number
+  body?: string
+  refreshInterval?: number
+  zoom?: number
+  healthStatus?: 'healthy' | 'unhealthy'
+  healthError?: string
+  feedMaxItems?: number
+  feedFilter?: string
+  feedFetchedAt?: string
+  folderId?: number | null
+  locationId?: number | null
+  tags?: string[]
+  version?: number
+  reviewStatus?: 'draft' | 'pending' | 'approved' | 'rejected'
+  reviewedBy?: string
+  reviewComment?: string
+  reviewedAt?: string
+  validFrom?: string | null
+  validUntil?: string | null
+  thumbnail?: string
+  fitMode?: 'fit' | 'fill' | 'crop'
+  focusX?: number | null // 0..1, точка фокуса для crop
+  focusY?: number | null
   createdAt?: string
   updatedAt?: string
 }
 
+export type ContentSearchParams = {
+  q?: string
+  type?: string[]
+  tag?: string[]
+  folderId?: number
+  locationId?: number
+  recursive?: boolean
+  uploadedFrom?: string
+  uploadedTo?: string
+  orientation?: string
+  reviewStatus?: string
+  page?: number
+  pageSize?: number
+}
+
+export type ContentPage = {
+  data: Content[]
+  total: number
+  page: number
+  pageSize: number
+}
+
+export type ContentVersion = {
+  id: number
+  contentId: number
+  version: number
+  size: number
+  checksum: string
+  mimeType: string
+  thumbnail?: string
+  uploadedBy?: string
+  restoredFrom?: number
+  createdAt: string
+}
+
+export type ImportReport = {
+  created: number
+  skipped: number
+  failed: number
+  results: { file: string; status: 'created' | 'skipped' | 'failed'; contentId?: number; error?: string }[]
+}
+
+export type StorageUsage = {
+  locations: { locationId: number; locationName: string; usedBytes: number; quota: number; files: number; contents: number }[]
+  shared: { usedBytes: number; files: number; contents: number }
+  totalBytes: number
+}
+
+export type ContentUsage = {
+  kind: 'template' | 'schedule' | 'playlist' | 'default_playlist'
+  id: number
+  name: string
+  blockId?: number
+  blockName?: string
+  itemId: number
+}
+
+export type Folder = {
+  id?: number
+  name: string
+  parentId?: number | null
+}
+
 export type ScheduleDay = {
   id?: number
   scheduleID?: number
@@ -62,6 +150,8 @@ export type Schedule = {
   name?: string
   description?: string
   groupId?: number
+  templateId?: number
+  templateVersion?: number // версия шаблона, из которой создано расписание
   monitors?: Array<{ id?: number }>
   createdAt?: string
   content?: Content
@@ -76,6 +166,73 @@ export type Template = {
   description?: string
   createdAt?: string
   blocks?: TemplateBlock[]
+  version?: number // последняя версия; растёт при каждом сохранении
+  validation?: ValidationReport // только в GET /templates/:id
+  // холст раскладки; без размеров — 1920×1080
+  canvasWidth?: number
+  canvasHeight?: number
+  zones?: TemplateZone[]
+}
+
+export type Diagnostic = {
+  severity: 'error' | 'warning' | 'info'
+  code: string // overlapping_blocks, missing_content, empty_block, uncovered_hours, ...
+  path?: string // "blocks[1].contents[0]"
+  block?: string
+  contentId?: number
+  message: string
+}
+
+export type ValidationReport = {
+  valid: boolean // нет ошибок — шаблон можно сохранить
+  diagnostics: Diagnostic[]
+}
+
+// Неизменяемый снимок шаблона; snapshot есть только у отдельной версии
+export type TemplateVersion = {
+  id: number
+  templateId: number
+  version: number
+  snapshot?: Template
+  createdAt: string
+}
+
+export type TemplateChange = {
+  path: string // "name", "canvas", "zones.<name>", "blocks.<id>.contents", ...
+  kind: 'added' | 'removed' | 'changed'
+  from?: any
+  to?: any
+}
+
+export type OutdatedSchedule = {
+  id: number
+  name: string
+  templateVersion: number
+  latestVersion: number
+}
+
+export type BundleImportReport = {
+  templateId?: number
+  name: string
+  // sourceId — ID контента в окружении, откуда экспортирован шаблон
+  contents: { sourceId: number; title: string; status?: 'matched' | 'created'; contentId?: number }[]
+  conflicts: {
+    kind: 'template_exists' | 'missing_content' | 'missing_media' | 'checksum_mismatch' | 'invalid_content' | 'invalid_template' | 'content_differs'
+    sourceId?: number
+    message: string
+  }[]
+  warnings?: string[]
+}
+
+export type TemplateZone = {
+  id?: number
+  name: string
+  x: number
+  y: number
+  width: number
+  height: number
+  zIndex?: number
+  playlistId?: number | null // играет, когда у блока нет элементов для области
 }
 
 export type TemplateBlock = {
@@ -84,12 +241,54 @@ export type TemplateBlock = {
   startTime: string // "HH:MM"
   endTime: string   // "HH:MM"
   contents: TemplateBlockContent[]
+  // link — контент берётся из плейлиста при каждом чтении, snapshot — копируется при сохранении
+  playlistId?: number | null
+  playlistMode?: PlaylistMode
+}
+
+export type PlaylistMode = 'link' | 'snapshot'
+
+export type Playlist = {
+  id?: number
+  name: string
+  description?: string
+  items: PlaylistItem[]
+  createdAt?: string
+  updatedAt?: string
+}
+
+// Ротация и окно действия работают в блоках расписаний; шаблон не принимает плейлист с окнами
+export type PlaylistItem = {
+  id?: number
+  contentId: number
+  content?: Content
+  position?: number
+  duration?: number | null
+  zone?: string
+  weight?: number
+  playsPerHour?: number
+  validFrom?: string | null
+  validUntil?: string | null
+  weekdays?: number[] // 1 — понедельник … 7 — воскресенье
+  startTime?: string // "HH:MM"
+  endTime?: string
+}
+
+export type PlaylistUsage = {
+  kind: 'template' | 'schedule'
+  id: number
+  name: string
+  blockId?: number
+  blockName?: string
+  zone?: string // ссылка из области шаблона, а не из блока
+  mode: PlaylistMode
 }
 
 export type TemplateBlockContent = {
   id?: number
   contentID: number
   duration?: number // seconds
+  zone?: string // пусто — основная область экрана
 }
 
 export const client = {
@@ -149,11 +348,14 @@ export const client = {
       return [] as Monitor[]
     }),
     create: (data: Omit<Monitor, 'id' | 'token' | 'createdAt'>) => api.post<Monitor>('/monitors', data).then((r: any) => r.data),
+    setResolution: (id: number, width: number, height: number) =>
+      api.put<Monitor>(`/monitors/${id}/resolution`, { width, height }).then((r: any) => r.data),
   },
 
   // Contents
   contents: {
-    getAll: () => api.get<Content[]>('/contents').then((r: any) => {
+    // для выпадающих списков: первая страница максимального размера
+    getAll: () => api.get<Content[]>('/contents', { params: { pageSize: 200 } }).then((r: any) => {
       const payload = r?.data
       if (Array.isArray(payload)) return payload
       if (payload && Array.isArray(payload.data)) return payload.data
@@ -163,7 +365,50 @@ export const client = {
     getById: (id: number) => api.get<Content>(`/contents/${id}`).then((r: any) => r.data),
     create: (data: Content) => api.post<Content>('/contents', data).then((r: any) => r.data),
     update: (id: number, data: Content) => api.put<Content>(`/contents/${id}`, data).then((r: any) => r.data),
-    delete: (id: number) => api.delete(`/contents/${id}`),
+    delete: (id: number, mode?: 'cascade' | 'replace', withId?: number) =>
+      api.delete(`/contents/${id}`, { params: { mode, with: withId } }),
+    usages: (id: number) => api.get<ContentUsage[]>(`/contents/${id}/usages`).then((r: any) => r.data),
+    submit: (id: number) => api.post<Content>(`/contents/${id}/submit`).then((r: any) => r.data),
+    approve: (id: number, comment?: string) =>
+      api.post<Content>(`/contents/${id}/approve`, { comment }).then((r: any) => r.data),
+    reject: (id: number, comment: string) =>
+      api.post<Content>(`/contents/${id}/reject`, { comment }).then((r: any) => r.data),
+    // archive — ZIP; manifest (manifest.json / .csv) можно передать отдельно или положить в архив
+    import: (archive: File, manifest?: File, folderId?: number, locationId?: number) => {
+      const form = new FormData()
+      form.append('archive', archive)
+      if (manifest) form.append('manifest', manifest)
+      if (folderId) form.append('folderId', String(folderId))
+      if (locationId) form.append('locationId', String(locationId))
+      return api.post<ImportReport>('/contents/import', form).then((r: any) => r.data)
+    },
+    versions: (id: number) => api.get<ContentVersion[]>(`/contents/${id}/versions`).then((r: any) => r.data),
+    rollback: (id: number, version: number) =>
+      api.post<Content>(`/contents/${id}/versions/${version}/rollback`).then((r: any) => r.data),
+    search: (params: ContentSearchParams) =>
+      api.get<ContentPage>('/contents', { params, paramsSerializer: { indexes: null } }).then((r: any) => r.data as ContentPage),
+    tags: () => api.get<{ tag: string; count: number }[]>('/contents/tags').then((r: any) => r.data),
+    thumbnailUrl: (id: number, size: 'small' | 'medium' | 'large' = 'medium') =>
+      `${API_BASE}/contents/${id}/thumbnail?size=${size}`,
+  },
+
+  // Storage: занятое место и квоты локаций
+  storage: {
+    usage: () => api.get<StorageUsage>('/storage/usage').then((r: any) => r.data),
+    largest: (locationId?: number, limit?: number) =>
+      api.get<Content[]>('/storage/largest', { params: { locationId, limit } }).then((r: any) => r.data),
+    unused: (locationId?: number, limit?: number) =>
+      api.get<Content[]>('/storage/unused', { params: { locationId, limit } }).then((r: any) => r.data),
+  },
+
+  // Playlists: общие списки контента для блоков шаблонов и расписаний
+  playlists: {
+    getAll: () => api.get<Playlist[]>('/playlists').then((r: any) => r.data),
+    getById: (id: number) => api.get<Playlist>(`/playlists/${id}`).then((r: any) => r.data),
+    create: (data: Playlist) => api.post<Playlist>('/playlists', data).then((r: any) => r.data),
+    update: (id: number, data: Playlist) => api.put<Playlist>(`/playlists/${id}`, data).then((r: any) => r.data),
+    delete: (id: number) => api.delete(`/playlists/${id}`),
+    usages: (id: number) => api.get<PlaylistUsage[]>(`/playlists/${id}/usages`).then((r: any) => r.data),
   },
 
   // Schedules
@@ -203,6 +448,23 @@ export const client = {
   update: (id: number, data: any) => api.put<Template>(`/templates/${id}`, data).then((r: any) => r.data),
   delete: (id: number) => api.delete(`/templates/${id}`),
   getById: (id: number) => api.get<Template>(`/templates/${id}`).then((r: any) => r.data),
+    validate: (data: Template) => api.post<ValidationReport>('/templates/validate', data).then((r: any) => r.data),
+    versions: (id: number) => api.get<TemplateVersion[]>(`/templates/${id}/versions`).then((r: any) => r.data),
+    getVersion: (id: number, version: number) =>
+      api.get<TemplateVersion>(`/templates/${id}/versions/${version}`).then((r: any) => r.data),
+    diffVersions: (id: number, from: number, to: number) =>
+      api.get<{ from: number; to: number; changes: TemplateChange[] }>(`/templates/${id}/versions/diff`, { params: { from, to } }).then((r: any) => r.data),
+    outdatedSchedules: (id: number) =>
+      api.get<OutdatedSchedule[]>(`/templates/${id}/outdated-schedules`).then((r: any) => r.data),
+    // media — вместе с файлами (только zip); ответ — файл пакета
+    exportBundle: (id: number, opts: { format?: 'json' | 'zip'; media?: boolean } = {}) =>
+      api.get(`/templates/${id}/export`, { params: opts, responseType: 'blob' }).then((r: any) => r.data as Blob),
+    // при конфликтах сервер отвечает 409 с отчётом в поле report
+    importBundle: (bundle: File, name?: string) => {
+      const form = new FormData()
+      form.append('bundle', bundle)
+      return api.post<BundleImportReport>('/templates/import', form, { params: name ? { name } : {} }).then((r: any) => r.data)
+    },
   },
 }
 
@@ -1,7 +1,14 @@
 import type {FormEvent} from 'react';
 import { useEffect, useState } from 'react';
 import { client} from '../api/client';
-import type { Content } from '../api/client'
+import type { Content, ContentUsage } from '../api/client'
+
+const reviewLabels: Record<string, string> = {
+  draft: 'Черновик',
+  pending: 'На согласовании',
+  approved: 'Одобрен',
+  rejected: 'Отклонён',
+}
 
 export default function ContentsPage() {
   const [contents, setContents] = useState<Content[]>([])
@@ -17,34 +24,35 @@ export default function ContentsPage() {
   const [error, setError] = useState('')
   const [success, setSuccess] = useState('')
 
-  const loadContents = async () => {
+  const [query, setQuery] = useState('')
+  const [typeFilter, setTypeFilter] = useState('')
+  const [page, setPage] = useState(1)
+  const [total, setTotal] = useState(0)
+  const pageSize = 50
+
+  const loadContents = async (targetPage = page) => {
     setLoading(true)
     try {
-      const data = await client.contents.getAll()
-
-      // Normalize/validate response: expect an array of contents.
-      if (Array.isArray(data)) {
-        setContents(data)
-        setError('')
-      } else if (data && Array.isArray((data as any).data)) {
-        // some APIs wrap payload in { data: [...] }
-        setContents((data as any).data)
-        setError('')
-      } else {
-        console.error('Unexpected /contents response shape:', data)
-        setContents([])
-        setError('Неверный ответ от сервера: ожидался массив контента')
-      }
+      const result = await client.contents.search({
+        q: query || undefined,
+        type: typeFilter ? [typeFilter] : undefined,
+        page: targetPage,
+        pageSize,
+      })
+      setContents(result.data)
+      setTotal(result.total)
+      setPage(result.page)
+      setError('')
     } catch (err: any) {
-      setError('Ошибка загрузки контента: ' + (err.message || 'Неизвестная ошибка'))
+      setError('Ошибка загрузки контента: ' + (err.response?.data?.error || err.message || 'Неизвестная ошибка'))
     } finally {
       setLoading(false)
     }
   }
 
   useEffect(() => {
-    loadContents()
-  }, [])
+    loadContents(1)
+  }, [typeFilter])
 
   const handleSubmit = async (e: FormEvent) => {
     e.preventDefault()
@@ -71,21 +79,49 @@ export default function ContentsPage() {
   const handleEdit = (content: Content) => {
     setFormData({
       title: content.title,
-      type: content.type,
+      type: content.type === 'url' ? 'web' : content.type,
       path: content.path,
+      body: content.body || '',
       description: content.description || '',
       duration: content.duration || 10,
+      validFrom: content.validFrom,
+      validUntil: content.validUntil,
     })
     setEditingId(content.id || null)
     setError('')
     setSuccess('')
   }
 
+  const handleReview = async (id: number, action: 'submit' | 'approve' | 'reject') => {
+    try {
+      if (action === 'reject') {
+        const comment = prompt('Причина отклонения')
+        if (!comment) return
+        await client.contents.reject(id, comment)
+      } else if (action === 'approve') {
+        await client.contents.approve(id)
+      } else {
+        await client.contents.submit(id)
+      }
+      loadContents(page)
+    } catch (err: any) {
+      setError('Ошибка согласования: ' + (err.response?.data?.error || err.message))
+    }
+  }
+
   const handleDelete = async (id: number) => {
     if (!confirm('Удалить этот контент?')) return
     
     try {
-      await client.contents.delete(id)
+      try {
+        await client.contents.delete(id)
+      } catch (err: any) {
+        if (err.response?.status !== 409) throw err
+        const usages: ContentUsage[] = err.response.data.usages || []
+        const places = usages.map((u) => `${u.name}${u.blockName ? ' / ' + u.blockName : ''}`).join('\n')
+        if (!confirm(`Контент используется:\n${places}\n\nУдалить его вместе с этими элементами?`)) return
+        await client.contents.delete(id, 'cascade')
+      }
       setSuccess('Контент удален')
       loadContents()
       setTimeout(() => setSuccess(''), 3000)
@@ -130,19 +166,36 @@ export default function ContentsPage() {
             >
               <option value="image">Изображение</option>
               <option value="video">Видео</option>
-              <option value="url">URL</option>
+              <option value="web">Веб-страница</option>
+              <option value="stream">Трансляция</option>
+              <option value="html5">HTML5-пакет</option>
+              <option value="text">Текст</option>
+              <option value="feed">RSS/Atom-лента</option>
             </select>
           </div>
-          <div className="form-group">
-            <label>Путь/URL *</label>
-            <input
-              type="text"
-              value={formData.path}
-              onChange={(e) => setFormData({ ...formData, path: e.target.value })}
-              placeholder="Путь к файлу или URL"
-              required
-            />
-          </div>
+          {formData.type === 'text' ? (
+            <div className="form-group">
+              <label>Текст *</label>
+              <textarea
+                value={formData.body || ''}
+                onChange={(e) => setFormData({ ...formData, body: e.target.value })}
+                rows={4}
+                placeholder="Текст сообщения"
+                required
+              />
+            </div>
+          ) : (
+            <div className="form-group">
+              <label>Путь/URL *</label>
+              <input
+                type="text"
+                value={formData.path}
+                onChange={(e) => setFormData({ ...formData, path: e.target.value })}
+                placeholder="Путь к файлу или URL"
+                required
+              />
+            </div>
+          )}
           <div className="form-row">
             <div className="form-group">
               <label>Длительность (секунды)</label>
@@ -153,6 +206,22 @@ export default function ContentsPage() {
                 min="1"
               />
             </div>
+            <div className="form-group">
+              <label>Показывать с</label>
+              <input
+                type="date"
+                value={formData.validFrom?.slice(0, 10) || ''}
+                onChange={(e) => setFormData({ ...formData, validFrom: e.target.value ? e.target.value + 'T00:00:00Z' : null })}
+              />
+            </div>
+            <div className="form-group">
+              <label>Показывать по</label>
+              <input
+                type="date"
+                value={formData.validUntil?.slice(0, 10) || ''}
+                onChange={(e) => setFormData({ ...formData, validUntil: e.target.value ? e.target.value + 'T00:00:00Z' : null })}
+              />
+            </div>
           </div>
           <div className="form-group">
             <label>Описание</label>
@@ -179,14 +248,40 @@ export default function ContentsPage() {
       <div className="card">
         <div className="toolbar">
           <h2 style={{ margin: 0, flex: 1 }}>Список контента</h2>
-          <button className="btn btn-secondary" onClick={loadContents} disabled={loading}>
+          <button className="btn btn-secondary" onClick={() => loadContents()} disabled={loading}>
             {loading ? 'Загрузка...' : 'Обновить'}
           </button>
         </div>
+        <form
+          className="toolbar"
+          onSubmit={(e) => {
+            e.preventDefault()
+            loadContents(1)
+          }}
+        >
+          <input
+            type="search"
+            value={query}
+            onChange={(e) => setQuery(e.target.value)}
+            placeholder="Поиск по названию и описанию"
+            style={{ flex: 1 }}
+          />
+          <select value={typeFilter} onChange={(e) => setTypeFilter(e.target.value)}>
+            <option value="">Все типы</option>
+            <option value="image">Изображения</option>
+            <option value="video">Видео</option>
+            <option value="web">Веб-страницы</option>
+            <option value="stream">Трансляции</option>
+            <option value="html5">HTML5-пакеты</option>
+            <option value="text">Тексты</option>
+            <option value="feed">Ленты</option>
+          </select>
+          <button type="submit" className="btn btn-primary">Найти</button>
+        </form>
 
         {contents.length === 0 ? (
           <div className="empty-state">
-            <p>Контента пока нет. Создайте первый контент выше.</p>
+            <p>{query || typeFilter ? 'Ничего не найдено.' : 'Контента пока нет. Создайте первый контент выше.'}</p>
           </div>
         ) : (
           <div className="table-container">
@@ -194,11 +289,13 @@ export default function ContentsPage() {
               <thead>
                 <tr>
                   <th>ID</th>
+                  <th>Превью</th>
                   <th>Название</th>
                   <th>Тип</th>
                   <th>Путь</th>
                   <th>Длительность</th>
                   <th>Описание</th>
+                  <th>Согласование</th>
                   <th>Действия</th>
                 </tr>
               </thead>
@@ -206,6 +303,17 @@ export default function ContentsPage() {
                 {contents.map((content, i) => (
                   <tr key={content.id ?? `content-${i}`}>
                     <td>{content.id}</td>
+                    <td>
+                      {content.thumbnail ? (
+                        <img
+                          src={client.contents.thumbnailUrl(content.id!, 'small')}
+                          alt={content.title}
+                          style={{ maxWidth: '80px', maxHeight: '60px', borderRadius: '4px' }}
+                        />
+                      ) : (
+                        '-'
+                      )}
+                    </td>
                     <td>{content.title}</td>
                     <td>
                       <span className="badge badge-primary" style={{ background: '#3498db' }}>
@@ -219,6 +327,24 @@ export default function ContentsPage() {
                     </td>
                     <td>{content.duration || '-'} сек</td>
                     <td>{content.description || '-'}</td>
+                    <td title={content.reviewComment || ''}>
+                      {reviewLabels[content.reviewStatus || 'draft']}
+                      {(content.reviewStatus === 'draft' || content.reviewStatus === 'rejected') && (
+                        <button className="btn btn-secondary" onClick={() => handleReview(content.id!, 'submit')}>
+                          На согласование
+                        </button>
+                      )}
+                      {content.reviewStatus === 'pending' && (
+                        <>
+                          <button className="btn btn-primary" onClick={() => handleReview(content.id!, 'approve')}>
+                            Одобрить
+                          </button>
+                          <button className="btn btn-danger" onClick={() => handleReview(content.id!, 'reject')}>
+                            Отклонить
+                          </button>
+                        </>
+                      )}
+                    </td>
                     <td>
                       <button
                         className="btn btn-secondary"
@@ -237,6 +363,23 @@ export default function ContentsPage() {
             </table>
           </div>
         )}
+        {total > pageSize && (
+          <div className="toolbar">
+            <button className="btn btn-secondary" disabled={page <= 1 || loading} onClick={() => loadContents(page - 1)}>
+              Назад
+            </button>
+            <span>
+              Страница {page} из {Math.ceil(total / pageSize)} (всего {total})
+            </span>
+            <button
+              className="btn btn-secondary"
+              disabled={page * pageSize >= total || loading}
+              onClick={() => loadContents(page + 1)}
+            >
+              Вперёд
+            </button>
+          </div>
+        )}
       </div>
     </div>
   )